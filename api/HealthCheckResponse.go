@@ -8,3 +8,18 @@ const (
 	Unhealthy
 	ApiError
 )
+
+func (code HealthCheckResponseCode) String() string {
+	switch code {
+	case RequestFailed:
+		return "RequestFailed"
+	case Healthy:
+		return "Healthy"
+	case Unhealthy:
+		return "Unhealthy"
+	case ApiError:
+		return "ApiError"
+	default:
+		return "Unknown"
+	}
+}
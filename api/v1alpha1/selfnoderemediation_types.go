@@ -22,8 +22,62 @@ import (
 )
 
 const (
-	ResourceDeletionRemediationStrategy       = RemediationStrategyType("ResourceDeletion")
-	DeprecatedNodeDeletionRemediationStrategy = RemediationStrategyType("NodeDeletion")
+	ResourceDeletionRemediationStrategy  = RemediationStrategyType("ResourceDeletion")
+	NodeDeletionRemediationStrategy      = RemediationStrategyType("NodeDeletion")
+	OutOfServiceTaintRemediationStrategy = RemediationStrategyType("OutOfServiceTaint")
+	AutomaticRemediationStrategy         = RemediationStrategyType("Automatic")
+	MachineDeletionRemediationStrategy   = RemediationStrategyType("MachineDeletion")
+
+	// Deprecated: NodeDeletion is supported again, use NodeDeletionRemediationStrategy
+	DeprecatedNodeDeletionRemediationStrategy = NodeDeletionRemediationStrategy
+
+	ApproveApprovalTimeoutAction = ApprovalTimeoutAction("Approve")
+	RejectApprovalTimeoutAction  = ApprovalTimeoutAction("Reject")
+
+	RestartKubeletEscalationAction          = EscalationAction("RestartKubelet")
+	RestartContainerRuntimeEscalationAction = EscalationAction("RestartContainerRuntime")
+	RebootEscalationAction                  = EscalationAction("Reboot")
+	PowerOffEscalationAction                = EscalationAction("PowerOff")
+
+	RebootPowerAction   = PowerActionType("Reboot")
+	PowerOffPowerAction = PowerActionType("PowerOff")
+	HaltPowerAction     = PowerActionType("Halt")
+)
+
+// condition types
+const (
+	// ProcessingConditionType is true while the remediation is in progress
+	ProcessingConditionType = "Processing"
+	// SucceededConditionType is true when the remediation finished successfully
+	SucceededConditionType = "Succeeded"
+	// DisabledConditionType is true when the remediation can't run, e.g. when the node can't reboot itself
+	DisabledConditionType = "Disabled"
+)
+
+// condition reasons, one for each step of the remediation
+const (
+	FinalizerAddedReason                = "FinalizerAdded"
+	NodeTaintedReason                   = "NodeTainted"
+	NodeCordonedReason                  = "NodeCordoned"
+	AwaitingRebootReason                = "AwaitingReboot"
+	FencingCompletedReason              = "FencingCompleted"
+	NodeRestoredReason                  = "NodeRestored"
+	NodeNotRebootCapableReason          = "NodeNotRebootCapable"
+	RemediationTimedOutReason           = "RemediationTimedOut"
+	TooManyRemediationsReason           = "TooManyRemediations"
+	RemediationQueuedReason             = "RemediationQueued"
+	RemediationStormReason              = "RemediationStorm"
+	DryRunCompletedReason               = "DryRunCompleted"
+	AwaitingApprovalReason              = "AwaitingApproval"
+	ApprovalRejectedReason              = "ApprovalRejected"
+	NodeUnderMaintenanceReason          = "NodeUnderMaintenance"
+	MachineConfigUpdateInProgressReason = "MachineConfigUpdateInProgress"
+	EtcdQuorumGuardReason               = "EtcdQuorumGuard"
+	EscalatingReason                    = "Escalating"
+	RecoveredByEscalationReason         = "RecoveredByEscalation"
+	NodeKeptDownReason                  = "NodeKeptDown"
+	FencingPostponedReason              = "FencingPostponed"
+	RebootNotConfirmedReason            = "RebootNotConfirmed"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -31,14 +85,172 @@ const (
 
 type RemediationStrategyType string
 
+type ApprovalTimeoutAction string
+
+type EscalationAction string
+
+type PowerActionType string
+
 // SelfNodeRemediationSpec defines the desired state of SelfNodeRemediation
 type SelfNodeRemediationSpec struct {
 	//RemediationStrategy is the remediation method for unhealthy nodes
-	//currently "NodeDeletion" is deprecated and "ResourceDeletion" will always happen, regardless of which strategy is selected
-	//it will iterate over all pods and volume attachments related to the unhealthy node and delete them
+	//"ResourceDeletion" will iterate over all pods and volume attachments related to the unhealthy node and delete them
+	//"OutOfServiceTaint" will add the out-of-service taint to the unhealthy node, and let kubernetes delete the pods
+	//and volume attachments (requires support for non-graceful node shutdown)
+	//"Automatic" will use "OutOfServiceTaint" if the cluster supports it, and "ResourceDeletion" otherwise
+	//"NodeDeletion" will delete the unhealthy node, and restore it from the node backup after the cluster
+	//rescheduled the affected workloads
+	//"MachineDeletion" will do the same as "ResourceDeletion", and then delete the machine which owns the SNR,
+	//so that a replacement machine is provisioned (requires a SNR which is owned by a Machine)
 	// +kubebuilder:default:="ResourceDeletion"
-	// +kubebuilder:validation:Enum=ResourceDeletion;NodeDeletion
+	// +kubebuilder:validation:Enum=ResourceDeletion;NodeDeletion;OutOfServiceTaint;Automatic;MachineDeletion
 	RemediationStrategy RemediationStrategyType `json:"remediationStrategy,omitempty"`
+
+	//NodeRestoreRules defines which labels, annotations and taints of the node backup are restored
+	//when the "NodeDeletion" strategy recreates the node. It's ignored by all other strategies.
+	// +optional
+	NodeRestoreRules *NodeRestoreRules `json:"nodeRestoreRules,omitempty"`
+
+	//RemediationTimeout is the time after which the remediation is marked as failed if it didn't complete,
+	//and isn't retried anymore. It's measured from the start of the remediation, so the time in which the remediation
+	//is queued or waits for an approval doesn't count. When the remediation times out, the node is made schedulable
+	//again. When not set, the remediationTimeout of the SelfNodeRemediationConfig is used.
+	//Valid time units are "ms", "s", "m", "h".
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ms|s|m|h)))$"
+	// +kubebuilder:validation:Type:=string
+	RemediationTimeout *metav1.Duration `json:"remediationTimeout,omitempty"`
+
+	//PodDeletionRules defines which pods of the unhealthy node are deleted, and how, by the "ResourceDeletion"
+	//and "MachineDeletion" strategies. When not set, all pods are force deleted. It's ignored by all other strategies.
+	// +optional
+	PodDeletionRules *PodDeletionRules `json:"podDeletionRules,omitempty"`
+
+	//DryRun indicates whether the remediation only records the actions it would take in the status and as events,
+	//without tainting, rebooting or deleting anything. It has no effect once the remediation started, so a started
+	//remediation is always completed and cleaned up
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	//ApprovalPolicy requires an approval before the unhealthy node is rebooted and its workloads are fenced.
+	//After tainting and cordoning the node, the remediation waits until the SelfNodeRemediation is annotated with
+	//"remediation-approval.self-node-remediation.medik8s.io" set to "approved" or "rejected".
+	//When not set, no approval is required.
+	// +optional
+	ApprovalPolicy *ApprovalPolicy `json:"approvalPolicy,omitempty"`
+
+	//EscalationSteps are ordered recovery steps which the agent on the unhealthy node executes before the node is
+	//fenced. The next step is executed when the node doesn't become Ready within the timeout of the current step.
+	//The node is fenced as usual when all steps failed, or when a "PowerOff" step is reached, which powers the node
+	//off instead of rebooting it. When not set, the node is fenced right away.
+	// +optional
+	EscalationSteps []EscalationStep `json:"escalationSteps,omitempty"`
+
+	//PowerAction is the action which takes the unhealthy node down
+	//"Reboot" reboots the node
+	//"PowerOff" powers the node off, and keeps it down until an operator powers it on again
+	//"Halt" disarms the watchdog and halts the node, and keeps it down until an operator resets it. The node is
+	//powered off instead when the watchdog can't be disarmed
+	//A node which can't access the api server is rebooted, and it's taken down again when it's back before the
+	//remediation completed
+	// +kubebuilder:default:="Reboot"
+	// +kubebuilder:validation:Enum=Reboot;PowerOff;Halt
+	// +optional
+	PowerAction PowerActionType `json:"powerAction,omitempty"`
+}
+
+// EscalationStep is a single recovery step of the escalation
+type EscalationStep struct {
+	//Action is the recovery action of the step
+	//"RestartKubelet" restarts the kubelet service
+	//"RestartContainerRuntime" restarts the CRI-O or containerd service
+	//"Reboot" reboots the node, when it's the last step the node is fenced as usual
+	//"PowerOff" fences the node, and powers it off regardless of the PowerAction
+	// +kubebuilder:validation:Enum=RestartKubelet;RestartContainerRuntime;Reboot;PowerOff
+	Action EscalationAction `json:"action"`
+
+	//Timeout is the time the node has to become Ready again after the action was executed,
+	//after which the next step is executed.
+	//Valid time units are "s", "m", "h".
+	// +kubebuilder:default:="2m"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ApprovalPolicy defines how long the remediation waits for an approval, and what happens when it doesn't arrive
+type ApprovalPolicy struct {
+	//Timeout is the time the remediation waits for an approval, after which the TimeoutAction is taken.
+	//It waits forever when empty or 0 (which is the default).
+	//Valid time units are "ms", "s", "m", "h".
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ms|s|m|h)))$"
+	// +kubebuilder:validation:Type:=string
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	//TimeoutAction is the action which is taken when the approval times out
+	//"Approve" continues the remediation as if it was approved
+	//"Reject" marks the remediation as failed, as if it was rejected
+	// +kubebuilder:default:="Reject"
+	// +kubebuilder:validation:Enum=Approve;Reject
+	TimeoutAction ApprovalTimeoutAction `json:"timeoutAction,omitempty"`
+}
+
+// PodDeletionRules defines which pods are skipped or deleted gracefully instead of being force deleted.
+// Skipping a pod takes precedence over deleting it gracefully.
+type PodDeletionRules struct {
+	//ExcludedPods selects pods which aren't deleted
+	// +optional
+	ExcludedPods *metav1.LabelSelector `json:"excludedPods,omitempty"`
+
+	//ExcludedNamespaces selects namespaces whose pods aren't deleted
+	// +optional
+	ExcludedNamespaces *metav1.LabelSelector `json:"excludedNamespaces,omitempty"`
+
+	//GracefulPods selects pods which are deleted with their own grace period instead of being force deleted
+	// +optional
+	GracefulPods *metav1.LabelSelector `json:"gracefulPods,omitempty"`
+
+	//GracefulNamespaces selects namespaces whose pods are deleted with their own grace period instead of being force deleted
+	// +optional
+	GracefulNamespaces *metav1.LabelSelector `json:"gracefulNamespaces,omitempty"`
+
+	//SkipDaemonSetPods indicates whether pods which are owned by a DaemonSet aren't deleted
+	// +optional
+	SkipDaemonSetPods bool `json:"skipDaemonSetPods,omitempty"`
+
+	//SkipStaticPods indicates whether the mirror pods of static pods aren't deleted
+	// +optional
+	SkipStaticPods bool `json:"skipStaticPods,omitempty"`
+}
+
+// NodeRestoreRules defines which node metadata is restored when the node is recreated
+type NodeRestoreRules struct {
+	//Labels filters the labels to restore. When not set, all labels are restored
+	// +optional
+	Labels *RestoreFilter `json:"labels,omitempty"`
+
+	//Annotations filters the annotations to restore. When not set, all annotations are restored.
+	//Annotations which can't be reused by a new node, e.g. "k8s.ovn.org/*", are never restored
+	// +optional
+	Annotations *RestoreFilter `json:"annotations,omitempty"`
+
+	//Taints filters the taints to restore by their key. When not set, all taints are restored
+	// +optional
+	Taints *RestoreFilter `json:"taints,omitempty"`
+}
+
+// RestoreFilter filters keys by an allowlist and a denylist.
+// A key which ends with "*" matches all keys with the same prefix, e.g. "k8s.ovn.org/*"
+type RestoreFilter struct {
+	//Allowlist is the list of keys to restore. When empty, all keys are restored unless they are denied.
+	// +optional
+	Allowlist []string `json:"allowlist,omitempty"`
+
+	//Denylist is the list of keys which are never restored, it takes precedence over the allowlist
+	// +optional
+	Denylist []string `json:"denylist,omitempty"`
 }
 
 // SelfNodeRemediationStatus defines the observed state of SelfNodeRemediation
@@ -56,8 +268,22 @@ type SelfNodeRemediationStatus struct {
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	TimeAssumedRebooted *metav1.Time `json:"timeAssumedRebooted,omitempty"`
 
+	//RemediationStartTime is the time when the remediation started, once it passed the queue and the approval.
+	//The remediation timeout is measured from it
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	RemediationStartTime *metav1.Time `json:"remediationStartTime,omitempty"`
+
+	//NodeBootID is the boot ID which the host of the unhealthy node reported when its reboot started, or the boot ID
+	//in the status of the node if the host couldn't be asked and the node was ready. A different boot ID proves that
+	//the node was rebooted, so the remediation doesn't need to wait until TimeAssumedRebooted. It's empty when
+	//neither was available
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	NodeBootID string `json:"nodeBootID,omitempty"`
+
 	// Phase represents the current phase of remediation,
-	// One of: TBD
+	// One of: "Fencing-Completed", "Failed"
 	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	Phase *string `json:"phase,omitempty"`
@@ -66,6 +292,39 @@ type SelfNodeRemediationStatus struct {
 	// If no error occurred it would be empty
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	LastError string `json:"lastError,omitempty"`
+
+	// Conditions represents the observations of the remediation's current state.
+	// Known condition types are "Processing", "Succeeded" and "Disabled"
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// DryRunActions lists the actions which the remediation would have taken, if it wasn't a dry run
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	DryRunActions []string `json:"dryRunActions,omitempty"`
+
+	// ApprovalRequestedTime is the time since when the remediation waits for an approval
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	ApprovalRequestedTime *metav1.Time `json:"approvalRequestedTime,omitempty"`
+
+	// EscalationStep is the index of the current escalation step
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	EscalationStep *int `json:"escalationStep,omitempty"`
+
+	// EscalationStepStartTime is the time when the current escalation step started
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	EscalationStepStartTime *metav1.Time `json:"escalationStepStartTime,omitempty"`
+
+	// ExecutedEscalationStep is the index of the last escalation step whose action was executed by the agent
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	ExecutedEscalationStep *int `json:"executedEscalationStep,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -18,6 +18,18 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// RemediationsHeldConditionType is true while the remediation schedule holds remediations which didn't start yet
+const RemediationsHeldConditionType = "RemediationsHeld"
+
+// condition reasons of the remediation schedule, which are used by the held SelfNodeRemediations as well
+const (
+	RemediationsAllowedReason      = "RemediationsAllowed"
+	RemediationsPausedReason       = "RemediationsPaused"
+	BlackoutWindowReason           = "BlackoutWindow"
+	OutsideMaintenanceWindowReason = "OutsideMaintenanceWindow"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -28,6 +40,7 @@ const (
 	defaultWatchdogPath                   = "/dev/watchdog"
 	defaultSafetToAssumeNodeRebootTimeout = 180
 	defaultIsSoftwareRebootEnabled        = true
+	DefaultEtcdCertsPath                  = "/etc/kubernetes/pki/etcd"
 )
 
 // SelfNodeRemediationConfigSpec defines the desired state of SelfNodeRemediationConfig
@@ -108,16 +121,327 @@ type SelfNodeRemediationConfigSpec struct {
 	// +kubebuilder:default=true
 	IsSoftwareRebootEnabled bool `json:"isSoftwareRebootEnabled,omitempty"`
 
+	// RebootMethods are the methods which self node remediation agents use for rebooting their node, in the given
+	// order. When a method fails, or the node isn't rebooted in time, the agent escalates to the next method:
+	// "Watchdog" stops feeding the watchdog device, "Systemctl" runs "systemctl reboot --force --force" on the host,
+	// "SysRq" writes to /proc/sysrq-trigger and "Syscall" calls the reboot syscall.
+	// "Crash" crashes the kernel through /proc/sysrq-trigger, so that hosts with kdump capture a vmcore for post-mortem
+	// diagnostics. It relies on kdump or on the kernel.panic sysctl for rebooting the node. It leaves the watchdog
+	// armed, so that the watchdog still reboots the node when the crash fails, which means that kdump must capture the
+	// vmcore within the watchdog timeout, or the kdump kernel must handle the watchdog. It isn't used by default.
+	// All methods except for "Watchdog" are software reboots, which are skipped when IsSoftwareRebootEnabled is false.
+	// When empty, "Watchdog", "Systemctl", "SysRq" and "Syscall" are used (which is the default).
+	// +optional
+	RebootMethods []RebootMethod `json:"rebootMethods,omitempty"`
+
+	// SysRqSync indicates whether the "SysRq" reboot method syncs the filesystems and remounts them read-only
+	// before rebooting the node. It's ignored by all other methods.
+	// +optional
+	SysRqSync bool `json:"sysRqSync,omitempty"`
+
+	// DiagnosticsBundlePath is the host directory to which self node remediation agents write a diagnostic bundle
+	// before they reboot their node, with their last health decisions, the last peer responses and the name of the
+	// SelfNodeRemediation. After the reboot, the agent reports a summary of the bundle as an event of the
+	// SelfNodeRemediation, or of the node if the SelfNodeRemediation doesn't exist anymore, and keeps the bundle with a
+	// timestamp suffix. It's disabled when empty (which is the default).
+	// +optional
+	DiagnosticsBundlePath string `json:"diagnosticsBundlePath,omitempty"`
+
+	// PingBeforeFencing makes the agents ping the unhealthy node, and ask a few peers to ping it, before they fence it
+	// when it doesn't answer on its peer health port. The node isn't fenced as long as it replies to any of the pings,
+	// since it might not have been rebooted. A node which answers on its peer health port is fenced only if its boot
+	// ID changed, regardless of this setting. See MaxFencingPostponement for how long fencing is postponed.
+	// It's disabled by default.
+	// +optional
+	PingBeforeFencing bool `json:"pingBeforeFencing,omitempty"`
+
+	// MaxFencingPostponement is the time for which the fencing of an unhealthy node, whose reboot isn't confirmed, is
+	// postponed after the node was assumed to be rebooted. The remediation is marked as failed when it's reached, and
+	// the node stays unschedulable until the SelfNodeRemediation is deleted, since it might still run its workloads.
+	// 0 disables the limit, so that fencing is postponed until the reboot is confirmed.
+	// When empty, 10 minutes are used (which is the default).
+	// Valid time units are "ms", "s", "m", "h".
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ms|s|m|h)))$"
+	// +kubebuilder:validation:Type:=string
+	MaxFencingPostponement *metav1.Duration `json:"maxFencingPostponement,omitempty"`
+
 	// EndpointHealthCheckUrl is an url that self node remediation agents which run on control-plane node will try to access when they can't contact their peers.
 	// This is a part of self diagnostics which will decide whether the node should be remediated or not.
 	// It will be ignored when empty (which is the default).
+	// Deprecated: use EndpointHealthChecks instead, a non-empty url is checked like an ICMP endpoint of EndpointHealthChecks.
 	EndpointHealthCheckUrl string `json:"endpointHealthCheckUrl,omitempty"`
+
+	// EndpointHealthChecks are endpoints that self node remediation agents which run on control-plane node will try to
+	// access when they can't contact their peers, together with the policy which decides whether they are accessible.
+	// This is a part of self diagnostics which will decide whether the node should be remediated or not, see the
+	// "Endpoints" check of SelfDiagnostics in order to check them on worker nodes as well.
+	// It will be ignored when empty (which is the default).
+	// +optional
+	EndpointHealthChecks *EndpointHealthChecks `json:"endpointHealthChecks,omitempty"`
+
+	// SelfDiagnostics configures the checks which self node remediation agents run on their node when they can't
+	// access the api server, and the peers don't tell whether the node is healthy, e.g. because most of them can't
+	// access the api server either. The node is remediated when the diagnostics fail.
+	// When empty, agents which run on control-plane nodes check the endpoints, the kubelet and etcd,
+	// and agents which run on worker nodes rely on their peers only (which is the default).
+	// +optional
+	SelfDiagnostics *SelfDiagnostics `json:"selfDiagnostics,omitempty"`
+
+	// SelfInitiatedRemediation enables self node remediation agents to remediate their own node when its self
+	// diagnostics keep failing although the api server is reachable, e.g. because the kubelet or the container runtime
+	// are down, so that such nodes are recovered without an external health check like NodeHealthCheck.
+	// See SelfDiagnostics for the checks, worker nodes don't have default checks.
+	// It's disabled when empty (which is the default).
+	// +optional
+	SelfInitiatedRemediation *SelfInitiatedRemediation `json:"selfInitiatedRemediation,omitempty"`
+
+	// EtcdCertsPath is the host path of the etcd certificates, which self node remediation agents which run on
+	// control-plane nodes use in order to check the health of the local etcd member as part of self diagnostics.
+	// It must contain the CA certificate "ca.crt", and the client certificate "healthcheck-client.crt" with its key
+	// "healthcheck-client.key", like the etcd static pod certificates of kubeadm clusters.
+	// The etcd health check is skipped when the certificates can't be found. Since the agents run on all nodes, the
+	// directory is created empty on nodes which don't have it, e.g. on worker nodes.
+	// +optional
+	// +kubebuilder:default:="/etc/kubernetes/pki/etcd"
+	EtcdCertsPath string `json:"etcdCertsPath,omitempty"`
+
+	// RemediationTimeout is the time after which a remediation which didn't complete is marked as failed,
+	// and isn't retried anymore. It's measured from the start of the remediation, after it was queued and approved,
+	// and the node is made schedulable again when it's reached. It can be overridden per SelfNodeRemediationTemplate.
+	// It's disabled when empty or 0 (which is the default).
+	// Valid time units are "ms", "s", "m", "h".
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ms|s|m|h)))$"
+	// +kubebuilder:validation:Type:=string
+	RemediationTimeout *metav1.Duration `json:"remediationTimeout,omitempty"`
+
+	// MaxRemediationsPerNode is the number of remediations a node can have within the RemediationsWindow.
+	// Further remediations of the node are marked as failed, since the node is considered to be broken
+	// in a way that rebooting it won't fix. It's disabled when 0 (which is the default).
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRemediationsPerNode int `json:"maxRemediationsPerNode,omitempty"`
+
+	// RemediationsWindow is the sliding window in which the remediations of a node are counted for MaxRemediationsPerNode.
+	// Valid time units are "ms", "s", "m", "h".
+	// +optional
+	// +kubebuilder:default:="24h"
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ms|s|m|h)))$"
+	// +kubebuilder:validation:Type:=string
+	RemediationsWindow *metav1.Duration `json:"remediationsWindow,omitempty"`
+
+	// MaxConcurrentRemediations is the maximum number, or percentage of the cluster's nodes, of nodes which are
+	// remediated at the same time. Further remediations are queued in the order of their creation until a running
+	// remediation completes. It's disabled when empty or 0 (which is the default).
+	// +optional
+	// +kubebuilder:validation:Pattern="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +kubebuilder:validation:XIntOrString
+	MaxConcurrentRemediations *intstr.IntOrString `json:"maxConcurrentRemediations,omitempty"`
+
+	// RemediationStormThreshold is the number, or percentage of the cluster's nodes, of unhealthy nodes which
+	// indicates a cluster wide problem rather than node failures. When it's reached, remediations which didn't start
+	// yet are queued until the number of unhealthy nodes drops below it.
+	// It's disabled when empty or 0 (which is the default).
+	// +optional
+	// +kubebuilder:validation:Pattern="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +kubebuilder:validation:XIntOrString
+	RemediationStormThreshold *intstr.IntOrString `json:"remediationStormThreshold,omitempty"`
+
+	// RemediationSchedule defines when remediations may start, e.g. in order to hold them during cluster upgrades.
+	// Held remediations start once the schedule allows it, remediations which already started aren't affected.
+	// When empty, remediations may start at any time (which is the default).
+	// +optional
+	RemediationSchedule *RemediationSchedule `json:"remediationSchedule,omitempty"`
+}
+
+// EndpointHealthCheckType is the type of an endpoint health check
+type EndpointHealthCheckType string
+
+// EndpointHealthCheckPolicy defines how many endpoints must be accessible
+type EndpointHealthCheckPolicy string
+
+const (
+	// ICMPEndpointHealthCheckType pings the endpoint
+	ICMPEndpointHealthCheckType EndpointHealthCheckType = "ICMP"
+	// TCPEndpointHealthCheckType opens a TCP connection to the endpoint
+	TCPEndpointHealthCheckType EndpointHealthCheckType = "TCP"
+	// HTTPEndpointHealthCheckType sends an HTTP or HTTPS GET request to the endpoint
+	HTTPEndpointHealthCheckType EndpointHealthCheckType = "HTTP"
+
+	// AnyEndpointHealthCheckPolicy requires at least one accessible endpoint
+	AnyEndpointHealthCheckPolicy EndpointHealthCheckPolicy = "Any"
+	// AllEndpointHealthCheckPolicy requires all endpoints to be accessible
+	AllEndpointHealthCheckPolicy EndpointHealthCheckPolicy = "All"
+	// MajorityEndpointHealthCheckPolicy requires more than half of the endpoints to be accessible
+	MajorityEndpointHealthCheckPolicy EndpointHealthCheckPolicy = "Majority"
+)
+
+// EndpointHealthChecks defines endpoints and how many of them must be accessible
+type EndpointHealthChecks struct {
+	// Endpoints are the endpoints to check
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []HealthCheckEndpoint `json:"endpoints"`
+
+	// Policy defines how many endpoints must be accessible: "Any" requires at least one of them,
+	// "All" requires all of them and "Majority" requires more than half of them.
+	// +kubebuilder:default:="Any"
+	// +kubebuilder:validation:Enum=Any;All;Majority
+	// +optional
+	Policy EndpointHealthCheckPolicy `json:"policy,omitempty"`
+}
+
+// HealthCheckEndpoint is an endpoint which is checked for accessibility
+type HealthCheckEndpoint struct {
+	// Type is the type of the check: "ICMP" pings the address, "TCP" opens a connection to it,
+	// and "HTTP" sends a GET request to it.
+	// +kubebuilder:validation:Enum=ICMP;TCP;HTTP
+	Type EndpointHealthCheckType `json:"type"`
+
+	// Address is the host for ICMP checks, "host:port" for TCP checks, and an http or https URL for HTTP checks
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// ExpectedStatusCode is the status code of an accessible endpoint for HTTP checks
+	// +kubebuilder:default:=200
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	// +optional
+	ExpectedStatusCode int `json:"expectedStatusCode,omitempty"`
+}
+
+// RebootMethod is a method for rebooting the node
+// +kubebuilder:validation:Enum=Watchdog;Systemctl;SysRq;Syscall;Crash
+type RebootMethod string
+
+const (
+	// WatchdogRebootMethod stops feeding the watchdog device
+	WatchdogRebootMethod RebootMethod = "Watchdog"
+	// SystemctlRebootMethod runs "systemctl reboot --force --force" on the host
+	SystemctlRebootMethod RebootMethod = "Systemctl"
+	// SysRqRebootMethod writes to /proc/sysrq-trigger
+	SysRqRebootMethod RebootMethod = "SysRq"
+	// SyscallRebootMethod calls the reboot syscall
+	SyscallRebootMethod RebootMethod = "Syscall"
+	// CrashRebootMethod crashes the kernel through /proc/sysrq-trigger, for capturing a vmcore by kdump
+	CrashRebootMethod RebootMethod = "Crash"
+)
+
+// DiagnosticsCheckName is the name of a built-in self diagnostics check
+type DiagnosticsCheckName string
+
+const (
+	// KubeletDiagnosticsCheck checks the health of the kubelet
+	KubeletDiagnosticsCheck DiagnosticsCheckName = "Kubelet"
+	// ContainerRuntimeDiagnosticsCheck checks that the socket of the container runtime accepts connections
+	ContainerRuntimeDiagnosticsCheck DiagnosticsCheckName = "ContainerRuntime"
+	// DiskPressureDiagnosticsCheck checks the available space of the root filesystem
+	DiskPressureDiagnosticsCheck DiagnosticsCheckName = "DiskPressure"
+	// NTPSyncDiagnosticsCheck checks that the system clock is synchronized
+	NTPSyncDiagnosticsCheck DiagnosticsCheckName = "NTPSync"
+	// EndpointsDiagnosticsCheck checks that the EndpointHealthChecks are still accessible
+	EndpointsDiagnosticsCheck DiagnosticsCheckName = "Endpoints"
+	// EtcdDiagnosticsCheck checks the health of the local etcd member, on control-plane nodes only
+	EtcdDiagnosticsCheck DiagnosticsCheckName = "Etcd"
+)
+
+// SelfDiagnostics defines the self diagnostics checks and when they fail
+type SelfDiagnostics struct {
+	// Checks are the checks to run
+	// +optional
+	Checks []DiagnosticsCheck `json:"checks,omitempty"`
+
+	// FailureThreshold is the total weight of failed checks from which the diagnostics fail
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+}
+
+// DiagnosticsCheck is a weighted self diagnostics check
+type DiagnosticsCheck struct {
+	// Name is the name of a built-in check: "Kubelet" checks the health of the kubelet, "ContainerRuntime" checks
+	// that the socket of the container runtime accepts connections, "DiskPressure" checks that the root filesystem has
+	// at least 10% of available space, "NTPSync" checks that the system clock is synchronized, "Endpoints" checks that
+	// the EndpointHealthChecks which were accessible when the agent started are still accessible, and "Etcd" checks
+	// the health of the local etcd member on control-plane nodes.
+	// +kubebuilder:validation:Enum=Kubelet;ContainerRuntime;DiskPressure;NTPSync;Endpoints;Etcd
+	Name DiagnosticsCheckName `json:"name"`
+
+	// Enabled indicates whether the check runs
+	// +kubebuilder:default:=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Weight is added to the total weight of failed checks when the check fails.
+	// A check with weight 0 is only logged.
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weight *int `json:"weight,omitempty"`
+}
+
+// SelfInitiatedRemediation defines when agents remediate their own node, and how
+type SelfInitiatedRemediation struct {
+	// FailureDuration is the time for which the self diagnostics must keep failing before the agent asks its peers
+	// to confirm that the node isn't ready. When a majority of the peers which respond confirms it, the agent creates
+	// a SelfNodeRemediation for its node, which is deleted once the node is ready again after the remediation.
+	// Valid time units are "s", "m", "h".
+	// +kubebuilder:default:="5m"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	// +optional
+	FailureDuration *metav1.Duration `json:"failureDuration,omitempty"`
+
+	// RemediationStrategy is the remediation strategy of the created SelfNodeRemediations
+	// +kubebuilder:default:="Automatic"
+	// +kubebuilder:validation:Enum=ResourceDeletion;OutOfServiceTaint;Automatic
+	// +optional
+	RemediationStrategy RemediationStrategyType `json:"remediationStrategy,omitempty"`
+}
+
+// RemediationSchedule defines when remediations may start
+type RemediationSchedule struct {
+	// Paused holds all remediations until it's unset
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// MaintenanceWindows are the windows in which remediations may start.
+	// When empty, remediations may start at any time, except for during blackout windows.
+	// +optional
+	MaintenanceWindows []ScheduleWindow `json:"maintenanceWindows,omitempty"`
+
+	// BlackoutWindows are the windows in which remediations may not start, even within a maintenance window
+	// +optional
+	BlackoutWindows []ScheduleWindow `json:"blackoutWindows,omitempty"`
+}
+
+// ScheduleWindow is a recurring time window
+type ScheduleWindow struct {
+	// Name identifies the window in events and conditions
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Schedule is a cron expression with the fields "minute hour day-of-month month day-of-week", which defines when
+	// the window starts, in UTC. E.g. "0 2 * * 6" starts the window every Saturday at 02:00.
+	Schedule string `json:"schedule"`
+
+	// Duration is the length of the window, at most 7 days.
+	// Valid time units are "s", "m", "h".
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	Duration metav1.Duration `json:"duration"`
 }
 
 // SelfNodeRemediationConfigStatus defines the observed state of SelfNodeRemediationConfig
 type SelfNodeRemediationConfigStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Conditions represents the observations of the SelfNodeRemediationConfig's current state.
+	// Known condition types are "RemediationsHeld"
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 //+kubebuilder:object:root=true
@@ -154,6 +478,7 @@ func NewDefaultSelfNodeRemediationConfig() SelfNodeRemediationConfig {
 			WatchdogFilePath:                    defaultWatchdogPath,
 			SafeTimeToAssumeNodeRebootedSeconds: defaultSafetToAssumeNodeRebootTimeout,
 			IsSoftwareRebootEnabled:             defaultIsSoftwareRebootEnabled,
+			EtcdCertsPath:                       DefaultEtcdCertsPath,
 		},
 	}
 }
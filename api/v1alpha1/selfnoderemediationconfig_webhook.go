@@ -18,7 +18,10 @@ package v1alpha1
 
 import (
 	"fmt"
+	"github.com/medik8s/self-node-remediation/pkg/schedule"
 	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -95,14 +98,14 @@ var _ webhook.Validator = &SelfNodeRemediationConfig{}
 func (r *SelfNodeRemediationConfig) ValidateCreate() error {
 	selfNodeRemediationConfigLog.Info("validate create", "name", r.Name)
 
-	return r.validateTimes()
+	return r.validate()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *SelfNodeRemediationConfig) ValidateUpdate(old runtime.Object) error {
 	selfNodeRemediationConfigLog.Info("validate update", "name", r.Name)
 
-	return r.validateTimes()
+	return r.validate()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -112,6 +115,75 @@ func (r *SelfNodeRemediationConfig) ValidateDelete() error {
 	return nil
 }
 
+func (r *SelfNodeRemediationConfig) validate() error {
+	if err := r.validateTimes(); err != nil {
+		return err
+	}
+	if err := r.validateEndpointHealthChecks(); err != nil {
+		return err
+	}
+	return r.validateRemediationSchedule()
+}
+
+// validateEndpointHealthChecks validates that the address of each endpoint matches its type
+func (r *SelfNodeRemediationConfig) validateEndpointHealthChecks() error {
+	if r.Spec.EndpointHealthChecks == nil {
+		return nil
+	}
+
+	errMsg := ""
+	for _, endpoint := range r.Spec.EndpointHealthChecks.Endpoints {
+		if err := endpoint.validate(); err != nil {
+			errMsg += fmt.Sprintf("\ninvalid %s endpoint %s: %v", endpoint.Type, endpoint.Address, err)
+		}
+	}
+
+	if errMsg != "" {
+		return fmt.Errorf(errMsg)
+	}
+	return nil
+}
+
+func (e *HealthCheckEndpoint) validate() error {
+	switch e.Type {
+	case TCPEndpointHealthCheckType:
+		if _, _, err := net.SplitHostPort(e.Address); err != nil {
+			return err
+		}
+	case HTTPEndpointHealthCheckType:
+		u, err := url.Parse(e.Address)
+		if err != nil {
+			return err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("address must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// validateRemediationSchedule validates the cron expressions and durations of the remediation schedule's windows
+func (r *SelfNodeRemediationConfig) validateRemediationSchedule() error {
+	if r.Spec.RemediationSchedule == nil {
+		return nil
+	}
+
+	errMsg := ""
+	var windows []ScheduleWindow
+	windows = append(windows, r.Spec.RemediationSchedule.MaintenanceWindows...)
+	windows = append(windows, r.Spec.RemediationSchedule.BlackoutWindows...)
+	for _, window := range windows {
+		if err := schedule.ValidateWindow(window.Schedule, window.Duration.Duration); err != nil {
+			errMsg += fmt.Sprintf("\ninvalid window %s: %v", window.Name, err)
+		}
+	}
+
+	if errMsg != "" {
+		return fmt.Errorf(errMsg)
+	}
+	return nil
+}
+
 // validateTimes validates that each time field in the SelfNodeRemediationConfig CR doesn't go below the minimum time
 // that was defined to it
 func (r *SelfNodeRemediationConfig) validateTimes() error {
@@ -54,6 +54,12 @@ var _ = Describe("SelfNodeRemediationConfig Validation", func() {
 		// test create validation on a valid CR
 		testValidCR("create")
 
+		// test create validation on CRs with an invalid remediation schedule
+		testInvalidRemediationSchedule("create")
+
+		// test create validation on CRs with invalid endpoint health checks
+		testInvalidEndpointHealthChecks("create")
+
 	})
 
 	Describe("updating SelfNodeRemediationConfig CR", func() {
@@ -66,6 +72,12 @@ var _ = Describe("SelfNodeRemediationConfig Validation", func() {
 		// test update validation on a valid CR
 		testValidCR("update")
 
+		// test update validation on CRs with an invalid remediation schedule
+		testInvalidRemediationSchedule("update")
+
+		// test update validation on CRs with invalid endpoint health checks
+		testInvalidEndpointHealthChecks("update")
+
 	})
 
 })
@@ -149,6 +161,69 @@ func testValidCR(validationType string) {
 	})
 }
 
+func testInvalidRemediationSchedule(validationType string) {
+	invalidWindows := map[string]ScheduleWindow{
+		"invalid cron expression": {Name: "invalid-cron", Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+		"zero duration":           {Name: "zero-duration", Schedule: "0 2 * * *"},
+		"too long duration":       {Name: "long-duration", Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: 8 * 24 * time.Hour}},
+	}
+
+	for text, window := range invalidWindows {
+		window := window
+		Context("for window with "+text, func() {
+			It("should be rejected", func() {
+				snrc := createDefaultSelfNodeRemediationConfigCR()
+				snrc.Spec.RemediationSchedule = &RemediationSchedule{BlackoutWindows: []ScheduleWindow{window}}
+
+				var err error
+				if validationType == "update" {
+					snrcOld := createDefaultSelfNodeRemediationConfigCR()
+					err = snrc.ValidateUpdate(snrcOld)
+				} else {
+					err = snrc.ValidateCreate()
+				}
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid window " + window.Name))
+			})
+		})
+	}
+}
+
+func testInvalidEndpointHealthChecks(validationType string) {
+	invalidEndpoints := map[string]HealthCheckEndpoint{
+		"TCP address without port":    {Type: TCPEndpointHealthCheckType, Address: "api-int.example.com"},
+		"HTTP address without scheme": {Type: HTTPEndpointHealthCheckType, Address: "api-int.example.com:6443/readyz"},
+	}
+
+	for text, endpoint := range invalidEndpoints {
+		endpoint := endpoint
+		Context("for endpoint with "+text, func() {
+			It("should be rejected", func() {
+				snrc := createDefaultSelfNodeRemediationConfigCR()
+				snrc.Spec.EndpointHealthChecks = &EndpointHealthChecks{
+					Endpoints: []HealthCheckEndpoint{
+						{Type: ICMPEndpointHealthCheckType, Address: "10.0.0.1"},
+						endpoint,
+					},
+				}
+
+				var err error
+				if validationType == "update" {
+					snrcOld := createDefaultSelfNodeRemediationConfigCR()
+					err = snrc.ValidateUpdate(snrcOld)
+				} else {
+					err = snrc.ValidateCreate()
+				}
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid " + string(endpoint.Type) + " endpoint " + endpoint.Address))
+				Expect(err.Error()).ToNot(ContainSubstring(string(ICMPEndpointHealthCheckType)))
+			})
+		})
+	}
+}
+
 func createDefaultSelfNodeRemediationConfigCR() *SelfNodeRemediationConfig {
 	snrc := &SelfNodeRemediationConfig{}
 	snrc.Name = "test"
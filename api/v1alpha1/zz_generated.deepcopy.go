@@ -22,17 +22,291 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalPolicy) DeepCopyInto(out *ApprovalPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalPolicy.
+func (in *ApprovalPolicy) DeepCopy() *ApprovalPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiagnosticsCheck) DeepCopyInto(out *DiagnosticsCheck) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosticsCheck.
+func (in *DiagnosticsCheck) DeepCopy() *DiagnosticsCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DiagnosticsCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointHealthChecks) DeepCopyInto(out *EndpointHealthChecks) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]HealthCheckEndpoint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointHealthChecks.
+func (in *EndpointHealthChecks) DeepCopy() *EndpointHealthChecks {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointHealthChecks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EscalationStep) DeepCopyInto(out *EscalationStep) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EscalationStep.
+func (in *EscalationStep) DeepCopy() *EscalationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(EscalationStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckEndpoint) DeepCopyInto(out *HealthCheckEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckEndpoint.
+func (in *HealthCheckEndpoint) DeepCopy() *HealthCheckEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeRestoreRules) DeepCopyInto(out *NodeRestoreRules) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = new(RestoreFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = new(RestoreFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = new(RestoreFilter)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeRestoreRules.
+func (in *NodeRestoreRules) DeepCopy() *NodeRestoreRules {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeRestoreRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDeletionRules) DeepCopyInto(out *PodDeletionRules) {
+	*out = *in
+	if in.ExcludedPods != nil {
+		in, out := &in.ExcludedPods, &out.ExcludedPods
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludedNamespaces != nil {
+		in, out := &in.ExcludedNamespaces, &out.ExcludedNamespaces
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracefulPods != nil {
+		in, out := &in.GracefulPods, &out.GracefulPods
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracefulNamespaces != nil {
+		in, out := &in.GracefulNamespaces, &out.GracefulNamespaces
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDeletionRules.
+func (in *PodDeletionRules) DeepCopy() *PodDeletionRules {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDeletionRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationSchedule) DeepCopyInto(out *RemediationSchedule) {
+	*out = *in
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]ScheduleWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlackoutWindows != nil {
+		in, out := &in.BlackoutWindows, &out.BlackoutWindows
+		*out = make([]ScheduleWindow, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationSchedule.
+func (in *RemediationSchedule) DeepCopy() *RemediationSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreFilter) DeepCopyInto(out *RestoreFilter) {
+	*out = *in
+	if in.Allowlist != nil {
+		in, out := &in.Allowlist, &out.Allowlist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Denylist != nil {
+		in, out := &in.Denylist, &out.Denylist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreFilter.
+func (in *RestoreFilter) DeepCopy() *RestoreFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindow.
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelfDiagnostics) DeepCopyInto(out *SelfDiagnostics) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]DiagnosticsCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfDiagnostics.
+func (in *SelfDiagnostics) DeepCopy() *SelfDiagnostics {
+	if in == nil {
+		return nil
+	}
+	out := new(SelfDiagnostics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelfInitiatedRemediation) DeepCopyInto(out *SelfInitiatedRemediation) {
+	*out = *in
+	if in.FailureDuration != nil {
+		in, out := &in.FailureDuration, &out.FailureDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfInitiatedRemediation.
+func (in *SelfInitiatedRemediation) DeepCopy() *SelfInitiatedRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(SelfInitiatedRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfNodeRemediation) DeepCopyInto(out *SelfNodeRemediation) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -60,7 +334,7 @@ func (in *SelfNodeRemediationConfig) DeepCopyInto(out *SelfNodeRemediationConfig
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationConfig.
@@ -118,34 +392,84 @@ func (in *SelfNodeRemediationConfigSpec) DeepCopyInto(out *SelfNodeRemediationCo
 	*out = *in
 	if in.PeerApiServerTimeout != nil {
 		in, out := &in.PeerApiServerTimeout, &out.PeerApiServerTimeout
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.ApiCheckInterval != nil {
 		in, out := &in.ApiCheckInterval, &out.ApiCheckInterval
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.PeerUpdateInterval != nil {
 		in, out := &in.PeerUpdateInterval, &out.PeerUpdateInterval
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.ApiServerTimeout != nil {
 		in, out := &in.ApiServerTimeout, &out.ApiServerTimeout
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.PeerDialTimeout != nil {
 		in, out := &in.PeerDialTimeout, &out.PeerDialTimeout
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.PeerRequestTimeout != nil {
 		in, out := &in.PeerRequestTimeout, &out.PeerRequestTimeout
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RebootMethods != nil {
+		in, out := &in.RebootMethods, &out.RebootMethods
+		*out = make([]RebootMethod, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxFencingPostponement != nil {
+		in, out := &in.MaxFencingPostponement, &out.MaxFencingPostponement
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EndpointHealthChecks != nil {
+		in, out := &in.EndpointHealthChecks, &out.EndpointHealthChecks
+		*out = new(EndpointHealthChecks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SelfDiagnostics != nil {
+		in, out := &in.SelfDiagnostics, &out.SelfDiagnostics
+		*out = new(SelfDiagnostics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SelfInitiatedRemediation != nil {
+		in, out := &in.SelfInitiatedRemediation, &out.SelfInitiatedRemediation
+		*out = new(SelfInitiatedRemediation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemediationTimeout != nil {
+		in, out := &in.RemediationTimeout, &out.RemediationTimeout
+		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.RemediationsWindow != nil {
+		in, out := &in.RemediationsWindow, &out.RemediationsWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxConcurrentRemediations != nil {
+		in, out := &in.MaxConcurrentRemediations, &out.MaxConcurrentRemediations
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.RemediationStormThreshold != nil {
+		in, out := &in.RemediationStormThreshold, &out.RemediationStormThreshold
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.RemediationSchedule != nil {
+		in, out := &in.RemediationSchedule, &out.RemediationSchedule
+		*out = new(RemediationSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationConfigSpec.
@@ -161,6 +485,13 @@ func (in *SelfNodeRemediationConfigSpec) DeepCopy() *SelfNodeRemediationConfigSp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfNodeRemediationConfigStatus) DeepCopyInto(out *SelfNodeRemediationConfigStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationConfigStatus.
@@ -208,6 +539,33 @@ func (in *SelfNodeRemediationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfNodeRemediationSpec) DeepCopyInto(out *SelfNodeRemediationSpec) {
 	*out = *in
+	if in.NodeRestoreRules != nil {
+		in, out := &in.NodeRestoreRules, &out.NodeRestoreRules
+		*out = new(NodeRestoreRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemediationTimeout != nil {
+		in, out := &in.RemediationTimeout, &out.RemediationTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PodDeletionRules != nil {
+		in, out := &in.PodDeletionRules, &out.PodDeletionRules
+		*out = new(PodDeletionRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApprovalPolicy != nil {
+		in, out := &in.ApprovalPolicy, &out.ApprovalPolicy
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EscalationSteps != nil {
+		in, out := &in.EscalationSteps, &out.EscalationSteps
+		*out = make([]EscalationStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationSpec.
@@ -225,18 +583,52 @@ func (in *SelfNodeRemediationStatus) DeepCopyInto(out *SelfNodeRemediationStatus
 	*out = *in
 	if in.NodeBackup != nil {
 		in, out := &in.NodeBackup, &out.NodeBackup
-		*out = new(v1.Node)
+		*out = new(corev1.Node)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.TimeAssumedRebooted != nil {
 		in, out := &in.TimeAssumedRebooted, &out.TimeAssumedRebooted
 		*out = (*in).DeepCopy()
 	}
+	if in.RemediationStartTime != nil {
+		in, out := &in.RemediationStartTime, &out.RemediationStartTime
+		*out = (*in).DeepCopy()
+	}
 	if in.Phase != nil {
 		in, out := &in.Phase, &out.Phase
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DryRunActions != nil {
+		in, out := &in.DryRunActions, &out.DryRunActions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ApprovalRequestedTime != nil {
+		in, out := &in.ApprovalRequestedTime, &out.ApprovalRequestedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EscalationStep != nil {
+		in, out := &in.EscalationStep, &out.EscalationStep
+		*out = new(int)
+		**out = **in
+	}
+	if in.EscalationStepStartTime != nil {
+		in, out := &in.EscalationStepStartTime, &out.EscalationStepStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExecutedEscalationStep != nil {
+		in, out := &in.ExecutedEscalationStep, &out.ExecutedEscalationStep
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationStatus.
@@ -254,7 +646,7 @@ func (in *SelfNodeRemediationTemplate) DeepCopyInto(out *SelfNodeRemediationTemp
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -311,7 +703,7 @@ func (in *SelfNodeRemediationTemplateList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfNodeRemediationTemplateResource) DeepCopyInto(out *SelfNodeRemediationTemplateResource) {
 	*out = *in
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationTemplateResource.
@@ -327,7 +719,7 @@ func (in *SelfNodeRemediationTemplateResource) DeepCopy() *SelfNodeRemediationTe
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfNodeRemediationTemplateSpec) DeepCopyInto(out *SelfNodeRemediationTemplateSpec) {
 	*out = *in
-	out.Template = in.Template
+	in.Template.DeepCopyInto(&out.Template)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfNodeRemediationTemplateSpec.
@@ -18,6 +18,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,15 +30,21 @@ import (
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/postmortem"
 	"github.com/medik8s/self-node-remediation/pkg/reboot"
 	"github.com/medik8s/self-node-remediation/pkg/utils"
 )
@@ -44,11 +52,39 @@ import (
 const (
 	SNRFinalizer          = "self-node-remediation.medik8s.io/snr-finalizer"
 	fencingCompletedPhase = "Fencing-Completed"
+	failedPhase           = "Failed"
+	fencingCheckInterval  = 5 * time.Second
+	queuedCheckInterval   = 10 * time.Second
+	readyCheckInterval    = 10 * time.Second
+	capiMachineGroup      = "cluster.x-k8s.io"
+	nodeMaintenanceGroup  = "nodemaintenance.medik8s.io"
 	//Event const
-	eventTypeWarning              = "Warning"
-	eventReasonDeprecatedStrategy = "Deprecated Strategy"
+	eventTypeNormal              = "Normal"
+	eventTypeWarning             = "Warning"
+	eventReasonRemediationFailed = "RemediationFailed"
+	eventReasonRemediationQueued = "RemediationQueued"
+	eventReasonDryRun            = "DryRun"
+	eventReasonAwaitingApproval  = "AwaitingApproval"
+	eventReasonEscalation        = "Escalation"
+	eventReasonFencingPostponed  = "FencingPostponed"
+	// defaultEscalationStepTimeout is used for escalation steps without a timeout
+	defaultEscalationStepTimeout = 2 * time.Minute
 )
 
+// fencingFunc fences the unhealthy node once it is assumed to be rebooted.
+// It returns true when fencing is completed
+type fencingFunc func(node *v1.Node) (bool, error)
+
+// NodeProber probes the unhealthy node before it's fenced, in order to detect a reboot which silently failed, e.g.
+// because of a broken watchdog
+type NodeProber interface {
+	// GetBootInfo returns the boot ID and the uptime which the agent of the given node reports, or an error if it
+	// doesn't answer
+	GetBootInfo(node *v1.Node) (string, time.Duration, error)
+	// IsReachable returns true if the given node replies to the pings of this agent or of any of the asked peers
+	IsReachable(node *v1.Node) bool
+}
+
 var (
 	NodeUnschedulableTaint = &v1.Taint{
 		Key:    "node.kubernetes.io/unschedulable",
@@ -61,6 +97,49 @@ var (
 		Effect: v1.TaintEffectNoExecute,
 	}
 
+	// OutOfServiceTaint lets the pod GC and attach-detach controllers clean up the node (non-graceful node shutdown)
+	OutOfServiceTaint = &v1.Taint{
+		Key:    "node.kubernetes.io/out-of-service",
+		Value:  "nodeshutdown",
+		Effect: v1.TaintEffectNoExecute,
+	}
+
+	// containerRuntimeServices are the systemd services of CRI-O and containerd
+	containerRuntimeServices = []string{"crio", "containerd"}
+
+	// defaultAnnotationsRestoreFilter denies annotations which can't be reused by a new node
+	defaultAnnotationsRestoreFilter = &v1alpha1.RestoreFilter{
+		Denylist: []string{"k8s.ovn.org/*"},
+	}
+
+	conditionMessages = map[string]string{
+		v1alpha1.FinalizerAddedReason:                "Remediation started, finalizer was added",
+		v1alpha1.NodeTaintedReason:                   "Node was tainted with the NoExecute taint",
+		v1alpha1.NodeCordonedReason:                  "Node was marked as unschedulable",
+		v1alpha1.AwaitingRebootReason:                "Waiting until the node is assumed to be rebooted",
+		v1alpha1.FencingCompletedReason:              "Node was fenced, remediation completed",
+		v1alpha1.NodeKeptDownReason:                  "Node was fenced and deliberately kept down until an operator intervenes, remediation completed",
+		v1alpha1.NodeRestoredReason:                  "Node was deleted and restored, remediation completed",
+		v1alpha1.NodeNotRebootCapableReason:          "Node is not capable to reboot itself, remediation is disabled",
+		v1alpha1.RemediationTimedOutReason:           "Remediation didn't complete on time, it won't be retried",
+		v1alpha1.TooManyRemediationsReason:           "Node was remediated too many times recently, it won't be remediated again",
+		v1alpha1.RemediationQueuedReason:             "Remediation is queued until other remediations complete",
+		v1alpha1.RemediationStormReason:              "Remediation is paused since too many nodes are unhealthy",
+		v1alpha1.DryRunCompletedReason:               "Dry run completed, the actions which would have been taken are listed in the status",
+		v1alpha1.AwaitingApprovalReason:              "Node was tainted and cordoned, waiting for an approval to reboot it",
+		v1alpha1.ApprovalRejectedReason:              "Remediation wasn't approved, the node won't be rebooted",
+		v1alpha1.RemediationsPausedReason:            "Remediation is held since remediations are paused",
+		v1alpha1.BlackoutWindowReason:                "Remediation is held during a blackout window",
+		v1alpha1.OutsideMaintenanceWindowReason:      "Remediation is held until the next maintenance window",
+		v1alpha1.NodeUnderMaintenanceReason:          "Remediation is deferred since the node is under maintenance",
+		v1alpha1.MachineConfigUpdateInProgressReason: "Remediation is deferred since the node is being updated by the machine-config-operator",
+		v1alpha1.EtcdQuorumGuardReason:               "Remediation is held since rebooting the control-plane node would break the etcd quorum",
+		v1alpha1.EscalatingReason:                    "Executing the escalation steps, waiting for the node to become ready",
+		v1alpha1.RecoveredByEscalationReason:         "Node became ready during the escalation steps, remediation completed",
+		v1alpha1.FencingPostponedReason:              "Fencing is postponed since the reboot of the node isn't confirmed",
+		v1alpha1.RebootNotConfirmedReason:            "Reboot of the node wasn't confirmed on time, the node won't be fenced",
+	}
+
 	lastSeenSnrNamespace  string
 	wasLastSeenSnrMachine bool
 )
@@ -97,6 +176,9 @@ type SelfNodeRemediationReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 	Rebooter reboot.Rebooter
+	//APIReader reads directly from the api server, it's used for queries which the cache can't serve efficiently,
+	//e.g. listing the pods of a single node
+	APIReader client.Reader
 	// note that this time must include the time for a unhealthy node without api-server access to reach the conclusion that it's unhealthy
 	// this should be at least worst-case time to reach a conclusion from the other peers * request context timeout + watchdog interval + maxFailuresThreshold * reconcileInterval + padding
 	SafeTimeToAssumeNodeRebooted time.Duration
@@ -107,6 +189,32 @@ type SelfNodeRemediationReconciler struct {
 	//40s of grace period for the node to reappear before it deletes the pods.
 	//see here: https://github.com/kubernetes/kubernetes/blob/7a0638da76cb9843def65708b661d2c6aa58ed5a/pkg/controller/podgc/gc_controller.go#L43-L47
 	RestoreNodeAfter time.Duration
+	//RemediationTimeout is the time after which a remediation which didn't complete is marked as failed,
+	//unless the snr overrides it. 0 means there's no timeout
+	RemediationTimeout time.Duration
+	//MaxRemediationsPerNode is the number of remediations a node can have within RemediationsWindow,
+	//further remediations are marked as failed. 0 means there's no limit
+	MaxRemediationsPerNode int
+	RemediationsWindow     time.Duration
+	//MaxConcurrentRemediations is the number, or percentage of nodes, of nodes which can be remediated at the same time.
+	//nil means there's no limit
+	MaxConcurrentRemediations *intstr.IntOrString
+	//RemediationStormThreshold is the number, or percentage of nodes, of unhealthy nodes which pauses remediations
+	//which didn't start yet. nil means it's disabled
+	RemediationStormThreshold *intstr.IntOrString
+	//ConfigNamespace is the namespace of the SelfNodeRemediationConfig, whose remediation schedule might hold
+	//remediations. Empty means that the remediation schedule is ignored
+	ConfigNamespace string
+	//PostMortem collects the diagnostic bundle which is written before the node is taken down, it may be nil
+	PostMortem *postmortem.Collector
+	//RebootMarker is written before the node is taken down, it may be nil
+	RebootMarker *postmortem.RebootMarker
+	//NodeProber confirms that the unhealthy node was rebooted, or is unreachable, before it's fenced once
+	//TimeAssumedRebooted has passed. nil means that the node isn't probed
+	NodeProber NodeProber
+	//MaxFencingPostponement is the time after TimeAssumedRebooted, after which a remediation whose reboot still isn't
+	//confirmed is marked as failed. 0 means there's no limit
+	MaxFencingPostponement time.Duration
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -125,7 +233,9 @@ func (r *SelfNodeRemediationReconciler) SetupWithManager(mgr ctrl.Manager) error
 //+kubebuilder:rbac:groups=self-node-remediation.medik8s.io,resources=selfnoderemediations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=self-node-remediation.medik8s.io,resources=selfnoderemediations/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=machine.openshift.io,resources=machines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=machine.openshift.io,resources=machines,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=nodemaintenance.medik8s.io,resources=nodemaintenances,verbs=get;list;watch
 
 func (r *SelfNodeRemediationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	r.logger = r.Log.WithValues("selfnoderemediation", req.NamespacedName)
@@ -145,17 +255,25 @@ func (r *SelfNodeRemediationReconciler) Reconcile(ctx context.Context, req ctrl.
 	lastSeenSnrNamespace = req.Namespace
 	r.mutex.Unlock()
 
+	// a remediation which already started isn't turned into a dry run, so that it's completed and cleaned up
+	if isDryRun(snr) {
+		result, err := r.remediateDryRun(snr)
+		return result, r.updateSnrStatusLastError(snr, err)
+	}
+
 	result := ctrl.Result{}
 	var err error
 
-	switch snr.Spec.RemediationStrategy {
-	case v1alpha1.ResourceDeletionRemediationStrategy, v1alpha1.DeprecatedNodeDeletionRemediationStrategy:
-		if snr.Spec.RemediationStrategy == v1alpha1.DeprecatedNodeDeletionRemediationStrategy {
-			msg := "`Node Deletion` remediation strategy is deprecated, using `Resource Deletion` remediation strategy instead"
-			r.logger.Info(msg)
-			r.Recorder.Eventf(snr, eventTypeWarning, eventReasonDeprecatedStrategy, msg)
-		}
+	strategy := r.getRuntimeStrategy(snr)
+	switch strategy {
+	case v1alpha1.ResourceDeletionRemediationStrategy:
 		result, err = r.remediateWithResourceDeletion(snr)
+	case v1alpha1.NodeDeletionRemediationStrategy:
+		result, err = r.remediateWithNodeDeletion(snr)
+	case v1alpha1.OutOfServiceTaintRemediationStrategy:
+		result, err = r.remediateWithOutOfServiceTaint(snr)
+	case v1alpha1.MachineDeletionRemediationStrategy:
+		result, err = r.remediateWithMachineDeletion(snr)
 	default:
 		//this should never happen since we enforce valid values with kubebuilder
 		err := errors.New("unsupported remediation strategy")
@@ -165,19 +283,78 @@ func (r *SelfNodeRemediationReconciler) Reconcile(ctx context.Context, req ctrl.
 	return result, r.updateSnrStatusLastError(snr, err)
 }
 
+// getRuntimeStrategy returns the strategy which should be used for the given snr,
+// which is the one from the spec, unless it's the automatic one
+func (r *SelfNodeRemediationReconciler) getRuntimeStrategy(snr *v1alpha1.SelfNodeRemediation) v1alpha1.RemediationStrategyType {
+	strategy := snr.Spec.RemediationStrategy
+	if strategy != v1alpha1.AutomaticRemediationStrategy {
+		return strategy
+	}
+
+	if utils.IsOutOfServiceTaintSupported {
+		strategy = v1alpha1.OutOfServiceTaintRemediationStrategy
+	} else {
+		strategy = v1alpha1.ResourceDeletionRemediationStrategy
+	}
+	r.logger.Info("Automatic remediation strategy selected", "strategy", strategy)
+	return strategy
+}
+
 func (r *SelfNodeRemediationReconciler) isFencingCompleted(snr *v1alpha1.SelfNodeRemediation) bool {
 	return snr.Status.Phase != nil && *snr.Status.Phase == fencingCompletedPhase && snr.DeletionTimestamp != nil
 }
 
 func (r *SelfNodeRemediationReconciler) remediateWithResourceDeletion(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	return r.remediate(snr, r.deleteResources(snr.Spec.PodDeletionRules))
+}
+
+func (r *SelfNodeRemediationReconciler) remediateWithOutOfServiceTaint(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	return r.remediate(snr, r.fenceWithOutOfServiceTaint)
+}
+
+func (r *SelfNodeRemediationReconciler) remediateWithNodeDeletion(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	if _, err := r.getNodeFromSnr(snr); err != nil {
+		if apiErrors.IsNotFound(err) {
+			// the node was deleted as part of the remediation, restore it
+			return r.handleDeletedNode(snr)
+		}
+		r.logger.Error(err, "failed to get node", "node name", snr.Name)
+		return ctrl.Result{}, err
+	}
+	return r.remediate(snr, r.deleteNode)
+}
+
+func (r *SelfNodeRemediationReconciler) remediateWithMachineDeletion(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	machineRef := getMachineOwnerRef(snr)
+	if machineRef == nil {
+		return ctrl.Result{}, &UnreconcilableError{"MachineDeletion remediation strategy requires a SelfNodeRemediation which is owned by a machine"}
+	}
+
+	if snr.Status.Phase != nil && *snr.Status.Phase == fencingCompletedPhase {
+		// the node is deleted together with its machine, so there is nothing to clean up on the node
+		if snr.DeletionTimestamp != nil && controllerutil.ContainsFinalizer(snr, SNRFinalizer) {
+			r.logger.Info("machine deleted, removing finalizer")
+			return ctrl.Result{}, r.removeFinalizer(snr)
+		}
+		r.logger.Info("machine deleted, waiting for the snr to be deleted")
+		return ctrl.Result{}, nil
+	}
+
+	return r.remediate(snr, r.deleteResourcesAndMachine(*machineRef, snr.Namespace, snr.Spec.PodDeletionRules))
+}
+
+// remediate runs the remediation flow which is common to all strategies, and fences the node with the given
+// fencing function once the node is assumed to be rebooted
+func (r *SelfNodeRemediationReconciler) remediate(snr *v1alpha1.SelfNodeRemediation, fence fencingFunc) (ctrl.Result, error) {
 	node, err := r.getNodeFromSnr(snr)
 	if err != nil {
 		r.logger.Error(err, "failed to get node", "node name", snr.Name)
 		return ctrl.Result{}, err
 	}
 
-	if r.isFencingCompleted(snr) {
-		r.logger.Info("fencing completed, cleaning up")
+	// a timed out remediation doesn't keep the node unschedulable until the snr is deleted
+	if r.isFencingCompleted(snr) || (isRemediationFailed(snr) && (snr.DeletionTimestamp != nil || isRemediationTimedOut(snr))) {
+		r.logger.Info("remediation is done, cleaning up")
 		if node.Spec.Unschedulable {
 			node.Spec.Unschedulable = false
 			if err := r.Client.Update(context.Background(), node); err != nil {
@@ -198,6 +375,10 @@ func (r *SelfNodeRemediationReconciler) remediateWithResourceDeletion(snr *v1alp
 			return ctrl.Result{}, err
 		}
 
+		if err := r.removeOutOfServiceTaint(node); err != nil {
+			return ctrl.Result{}, err
+		}
+
 		if controllerutil.ContainsFinalizer(snr, SNRFinalizer) {
 			if err := r.removeFinalizer(snr); err != nil {
 				return ctrl.Result{}, err
@@ -207,112 +388,1271 @@ func (r *SelfNodeRemediationReconciler) remediateWithResourceDeletion(snr *v1alp
 		return ctrl.Result{}, nil
 	}
 
-	r.logger.Info("fencing not completed yet, continuing remediation")
+	if snr.Status.Phase != nil && *snr.Status.Phase == fencingCompletedPhase {
+		if isSelfInitiatedRemediation(snr) {
+			return r.deleteSelfInitiatedRemediation(snr, node)
+		}
+		// e.g. a node which was deleted and restored must not be deleted again
+		r.logger.Info("fencing completed, waiting for the snr to be deleted")
+		return ctrl.Result{}, nil
+	}
+
+	if isRemediationFailed(snr) {
+		r.logger.Info("remediation failed, waiting for the snr to be deleted")
+		return ctrl.Result{}, nil
+	}
+
+	if isRecoveredByEscalation(snr) {
+		if isSelfInitiatedRemediation(snr) {
+			return r.deleteSelfInitiatedRemediation(snr, node)
+		}
+		r.logger.Info("node recovered during escalation, waiting for the snr to be deleted")
+		return ctrl.Result{}, nil
+	}
+
+	// the time in which the remediation was queued or waited for an approval doesn't count
+	if timeout := r.getRemediationTimeout(snr); timeout > 0 && snr.Status.RemediationStartTime != nil &&
+		time.Now().After(snr.Status.RemediationStartTime.Add(timeout)) {
+		return r.markRemediationFailed(snr, v1alpha1.RemediationTimedOutReason,
+			fmt.Sprintf("Remediation of node %s didn't complete within %s", node.Name, timeout))
+	}
+
+	r.logger.Info("fencing not completed yet, continuing remediation")
+
+	if !r.isNodeRebootCapable(node) {
+		if err := r.updateConditions(snr, v1alpha1.NodeNotRebootCapableReason); err != nil {
+			return ctrl.Result{}, err
+		}
+		//use err to trigger exponential backoff
+		return ctrl.Result{}, errors.New("Node is not capable to reboot itself")
+	}
+
+	// a planned drain and reboot must not be disturbed by another reboot and the force deletion of pods
+	maintenanceReason, message, err := r.getNodeMaintenanceReason(node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if maintenanceReason != "" {
+		return r.queueRemediation(snr, maintenanceReason, message)
+	}
+
+	if !controllerutil.ContainsFinalizer(snr, SNRFinalizer) {
+		queuedReason, message, err := r.getQueuedReason(snr, node)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if queuedReason != "" {
+			return r.queueRemediation(snr, queuedReason, message)
+		}
+
+		// the node is fenced only if the escalation steps didn't recover it
+		if snr.DeletionTimestamp.IsZero() {
+			if shouldFence, result, err := r.escalate(snr, node); !shouldFence {
+				return result, err
+			}
+		}
+
+		if snr.DeletionTimestamp.IsZero() && r.MaxRemediationsPerNode > 0 {
+			allowed, err := r.recordRemediation(node, snr)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !allowed {
+				return r.markRemediationFailed(snr, v1alpha1.TooManyRemediationsReason,
+					fmt.Sprintf("Node %s was already remediated %d times within %s", node.Name, r.MaxRemediationsPerNode, r.RemediationsWindow))
+			}
+		}
+		return r.addFinalizer(snr)
+	}
+
+	if err = r.addNoExecuteTaint(node); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !node.Spec.Unschedulable || !utils.TaintExists(node.Spec.Taints, NodeUnschedulableTaint) {
+		if err := r.updateConditions(snr, v1alpha1.NodeTaintedReason); err != nil {
+			return ctrl.Result{}, err
+		}
+		return r.markNodeAsUnschedulable(node)
+	}
+
+	// the approval must be given before the time to assume that the node is rebooted starts
+	if snr.Status.TimeAssumedRebooted.IsZero() && snr.Spec.ApprovalPolicy != nil {
+		if approved, result, err := r.waitForApproval(snr); !approved {
+			return result, err
+		}
+	}
+
+	if snr.Status.TimeAssumedRebooted.IsZero() {
+		// the conditions are updated together with the rest of the status
+		setConditions(snr, v1alpha1.NodeCordonedReason)
+		//todo this also updates the node but we don't need it
+		return r.updateSnrStatus(node, snr)
+	}
+
+	// a postponed fencing is reported until the reboot is confirmed
+	if !isFencingPostponed(snr) {
+		if err := r.updateConditions(snr, v1alpha1.AwaitingRebootReason); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if r.MyNodeName == node.Name {
+		// we have a problem on this node, reboot!
+		return r.rebootIfNeeded(snr)
+	}
+
+	wasRebooted, timeLeft := r.wasNodeRebooted(snr, node)
+	if !wasRebooted {
+		return ctrl.Result{RequeueAfter: timeLeft}, nil
+	}
+
+	// a changed boot ID already proves the reboot
+	if !isBootIDChanged(snr, r.getNodeStatusBootID(node)) {
+		if confirmed, message := r.isRebootConfirmed(snr, node); !confirmed {
+			return r.postponeFencing(snr, node, message)
+		}
+	}
+
+	completed, err := fence(node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !completed {
+		return ctrl.Result{RequeueAfter: fencingCheckInterval}, nil
+	}
+
+	if getPowerAction(snr) != v1alpha1.RebootPowerAction {
+		return r.markFencingCompleted(snr, v1alpha1.NodeKeptDownReason)
+	}
+	return r.markFencingCompleted(snr, v1alpha1.FencingCompletedReason)
+}
+
+// isSelfInitiatedRemediation returns true if the agent of the unhealthy node created the given snr itself
+func isSelfInitiatedRemediation(snr *v1alpha1.SelfNodeRemediation) bool {
+	_, exists := snr.Annotations[utils.SelfInitiatedRemediationAnnotation]
+	return exists
+}
+
+// deleteSelfInitiatedRemediation deletes a completed self-initiated remediation once the node is ready again,
+// since there is no external health check which deletes it
+func (r *SelfNodeRemediationReconciler) deleteSelfInitiatedRemediation(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) (ctrl.Result, error) {
+	if readyCond := r.getReadyCond(node); readyCond == nil || readyCond.Status != v1.ConditionTrue {
+		r.logger.Info("self-initiated remediation completed, waiting for the node to be ready", "node name", node.Name)
+		return ctrl.Result{RequeueAfter: readyCheckInterval}, nil
+	}
+
+	r.logger.Info("node is ready after a self-initiated remediation, deleting the snr", "node name", node.Name)
+	if err := r.Client.Delete(context.Background(), snr); err != nil && !apiErrors.IsNotFound(err) {
+		r.logger.Error(err, "failed to delete the self-initiated snr")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// escalate executes the escalation steps of the given snr until the node becomes ready again, or until a step
+// requires fencing the node. It returns true when the node must be fenced
+func (r *SelfNodeRemediationReconciler) escalate(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) (bool, ctrl.Result, error) {
+	steps := snr.Spec.EscalationSteps
+	index := getEscalationStep(snr)
+	if isFencingEscalationStep(steps, index) {
+		return true, ctrl.Result{}, nil
+	}
+
+	if snr.Status.EscalationStep == nil {
+		r.logger.Info("starting escalation", "node name", node.Name)
+		result, err := r.startEscalationStep(snr, index)
+		return false, result, err
+	}
+
+	if isNodeReadySince(node, snr.CreationTimestamp.Time) {
+		r.logger.Info("node became ready during escalation", "node name", node.Name, "escalation step", index)
+		result, err := r.markRecoveredByEscalation(snr)
+		return false, result, err
+	}
+
+	step := steps[index]
+	timeLeft := snr.Status.EscalationStepStartTime.Add(getEscalationStepTimeout(step)).Sub(time.Now())
+	if timeLeft <= 0 {
+		r.logger.Info("node didn't become ready within the escalation step timeout", "node name", node.Name, "escalation step", index)
+		result, err := r.startEscalationStep(snr, index+1)
+		if err == nil && result.Requeue {
+			r.Recorder.Event(snr, eventTypeWarning, eventReasonEscalation,
+				fmt.Sprintf("Escalation step %d (%s) didn't recover node %s within %s", index, step.Action, node.Name, getEscalationStepTimeout(step)))
+		}
+		return false, result, err
+	}
+
+	// the action is executed only once, by the agent of the unhealthy node
+	if r.MyNodeName == node.Name && (snr.Status.ExecutedEscalationStep == nil || *snr.Status.ExecutedEscalationStep < index) {
+		snr.Status.ExecutedEscalationStep = &index
+		if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+			if apiErrors.IsConflict(err) {
+				return false, ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+			}
+			r.logger.Error(err, "failed to update the executed escalation step")
+			return false, ctrl.Result{}, err
+		}
+
+		r.logger.Info("executing escalation step", "escalation step", index, "action", step.Action)
+		r.Recorder.Event(snr, eventTypeNormal, eventReasonEscalation, fmt.Sprintf("Executing escalation step %d (%s)", index, step.Action))
+		// a failed action isn't retried, the next step is executed once the timeout expires
+		if err := r.executeEscalationAction(snr, step.Action); err != nil {
+			r.logger.Error(err, "failed to execute escalation step", "escalation step", index, "action", step.Action)
+			r.Recorder.Event(snr, eventTypeWarning, eventReasonEscalation, fmt.Sprintf("Failed to execute escalation step %d (%s): %v", index, step.Action, err))
+		}
+	}
+
+	if timeLeft > readyCheckInterval {
+		timeLeft = readyCheckInterval
+	}
+	return false, ctrl.Result{RequeueAfter: timeLeft}, nil
+}
+
+// startEscalationStep makes the escalation step with the given index the current one
+func (r *SelfNodeRemediationReconciler) startEscalationStep(snr *v1alpha1.SelfNodeRemediation, index int) (ctrl.Result, error) {
+	now := metav1.Now()
+	snr.Status.EscalationStep = &index
+	snr.Status.EscalationStepStartTime = &now
+	setConditions(snr, v1alpha1.EscalatingReason)
+	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+		if apiErrors.IsConflict(err) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		r.logger.Error(err, "failed to update the escalation step")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// markRecoveredByEscalation marks the remediation as completed without fencing the node
+func (r *SelfNodeRemediationReconciler) markRecoveredByEscalation(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	setConditions(snr, v1alpha1.RecoveredByEscalationReason)
+	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+		if apiErrors.IsConflict(err) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		r.logger.Error(err, "failed to mark SNR as recovered by escalation")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// executeEscalationAction executes the given escalation action of the given snr on the host of the agent
+func (r *SelfNodeRemediationReconciler) executeEscalationAction(snr *v1alpha1.SelfNodeRemediation, action v1alpha1.EscalationAction) error {
+	switch action {
+	case v1alpha1.RestartKubeletEscalationAction:
+		_, err := utils.RunHostCommand("/bin/systemctl", "restart", "kubelet")
+		return err
+	case v1alpha1.RestartContainerRuntimeEscalationAction:
+		for _, service := range containerRuntimeServices {
+			// cat fails for services which aren't installed
+			if _, err := utils.RunHostCommand("/bin/systemctl", "cat", service); err != nil {
+				continue
+			}
+			_, err := utils.RunHostCommand("/bin/systemctl", "restart", service)
+			return err
+		}
+		return errors.New("no container runtime service found")
+	case v1alpha1.RebootEscalationAction:
+		r.writeRebootMarker(snr, "Node is rebooted by an escalation step of its remediation")
+		return r.Rebooter.Reboot()
+	default:
+		return fmt.Errorf("unsupported escalation action %s", action)
+	}
+}
+
+// getEscalationStep returns the index of the current escalation step of the given snr
+func getEscalationStep(snr *v1alpha1.SelfNodeRemediation) int {
+	if snr.Status.EscalationStep == nil {
+		return 0
+	}
+	return *snr.Status.EscalationStep
+}
+
+// isFencingEscalationStep returns true if the node is fenced at the escalation step with the given index, which is
+// the case for a "PowerOff" step, for a "Reboot" step which is the last one, and when all steps were executed
+func isFencingEscalationStep(steps []v1alpha1.EscalationStep, index int) bool {
+	if index >= len(steps) {
+		return true
+	}
+	action := steps[index].Action
+	return action == v1alpha1.PowerOffEscalationAction || (action == v1alpha1.RebootEscalationAction && index == len(steps)-1)
+}
+
+// getPowerAction returns the action which takes the node of the given snr down. A "PowerOff" escalation step
+// overrides the power action of the spec
+func getPowerAction(snr *v1alpha1.SelfNodeRemediation) v1alpha1.PowerActionType {
+	index := getEscalationStep(snr)
+	if index < len(snr.Spec.EscalationSteps) && snr.Spec.EscalationSteps[index].Action == v1alpha1.PowerOffEscalationAction {
+		return v1alpha1.PowerOffPowerAction
+	}
+	if snr.Spec.PowerAction == "" {
+		return v1alpha1.RebootPowerAction
+	}
+	return snr.Spec.PowerAction
+}
+
+func getEscalationStepTimeout(step v1alpha1.EscalationStep) time.Duration {
+	if step.Timeout == nil {
+		return defaultEscalationStepTimeout
+	}
+	return step.Timeout.Duration
+}
+
+// isNodeReadySince returns true if the given node became ready after the given time
+func isNodeReadySince(node *v1.Node, since time.Time) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue && cond.LastTransitionTime.After(since)
+		}
+	}
+	return false
+}
+
+func isRecoveredByEscalation(snr *v1alpha1.SelfNodeRemediation) bool {
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.ProcessingConditionType)
+	return condition != nil && condition.Reason == v1alpha1.RecoveredByEscalationReason
+}
+
+// getQueuedReason returns the reason for queueing the given snr, if it must not start yet because of the remediation
+// schedule, the concurrent remediations limit, a remediation storm or the etcd quorum guard. An empty reason means that
+// the remediation can start.
+func (r *SelfNodeRemediationReconciler) getQueuedReason(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) (string, string, error) {
+	if reason, message, err := r.getScheduleHoldReason(); err != nil || reason != "" {
+		return reason, message, err
+	}
+
+	isControlPlane := utils.IsControlPlaneNode(node)
+	if r.MaxConcurrentRemediations == nil && r.RemediationStormThreshold == nil && !isControlPlane {
+		return "", "", nil
+	}
+
+	nodes := &v1.NodeList{}
+	if err := r.Client.List(context.Background(), nodes); err != nil {
+		r.logger.Error(err, "failed to list nodes")
+		return "", "", err
+	}
+
+	snrs := &v1alpha1.SelfNodeRemediationList{}
+	if err := r.Client.List(context.Background(), snrs); err != nil {
+		r.logger.Error(err, "failed to list SNRs")
+		return "", "", err
+	}
+
+	if r.RemediationStormThreshold != nil {
+		threshold, err := intstr.GetScaledValueFromIntOrPercent(r.RemediationStormThreshold, len(nodes.Items), true)
+		if err != nil {
+			r.logger.Error(err, "invalid remediation storm threshold", "threshold", r.RemediationStormThreshold.String())
+			return "", "", err
+		}
+		// each snr which is being remediated or waits for its remediation represents an unhealthy node, finished
+		// remediations, dry runs and remediations of nodes under maintenance don't
+		unhealthyNodes := 0
+		for i := range snrs.Items {
+			other := &snrs.Items[i]
+			if other.UID == snr.UID || isRemediationActive(other) || isRemediationEscalating(other) || isRemediationPending(other) {
+				unhealthyNodes++
+			}
+		}
+		if threshold > 0 && unhealthyNodes >= threshold {
+			return v1alpha1.RemediationStormReason,
+				fmt.Sprintf("Remediation is paused since %d of %d nodes are unhealthy", unhealthyNodes, len(nodes.Items)), nil
+		}
+	}
+
+	if r.MaxConcurrentRemediations != nil {
+		maxRemediations, err := intstr.GetScaledValueFromIntOrPercent(r.MaxConcurrentRemediations, len(nodes.Items), true)
+		if err != nil {
+			r.logger.Error(err, "invalid max concurrent remediations", "max", r.MaxConcurrentRemediations.String())
+			return "", "", err
+		}
+		// the started remediations, and the pending ones which were created before this snr, take precedence.
+		// The agents of all nodes order the pending remediations the same way, so they let the same ones start
+		activeRemediations, precedingRemediations := 0, 0
+		for i := range snrs.Items {
+			other := &snrs.Items[i]
+			if other.UID == snr.UID {
+				continue
+			}
+			if isRemediationActive(other) || isRemediationEscalating(other) {
+				activeRemediations++
+			} else if isRemediationPending(other) && isCreatedBefore(other, snr) {
+				precedingRemediations++
+			}
+		}
+		if maxRemediations > 0 && activeRemediations+precedingRemediations >= maxRemediations {
+			return v1alpha1.RemediationQueuedReason,
+				fmt.Sprintf("Remediation is queued since %d nodes are already being remediated and %d remediations are queued before it",
+					activeRemediations, precedingRemediations), nil
+		}
+	}
+
+	if isControlPlane {
+		return r.getEtcdQuorumGuardReason(node, nodes.Items, snrs.Items)
+	}
+	return "", "", nil
+}
+
+// getEtcdQuorumGuardReason returns the reason for holding the remediation of the given control-plane node, if rebooting
+// it would break the etcd quorum because other control-plane nodes are NotReady or being remediated.
+// An empty reason means that the remediation can start.
+func (r *SelfNodeRemediationReconciler) getEtcdQuorumGuardReason(node *v1.Node, nodes []v1.Node, snrs []v1alpha1.SelfNodeRemediation) (string, string, error) {
+	controlPlaneNodes := map[string]bool{}
+	unavailableNodes := map[string]bool{}
+	for i := range nodes {
+		if !utils.IsControlPlaneNode(&nodes[i]) {
+			continue
+		}
+		controlPlaneNodes[nodes[i].Name] = true
+		if readyCond := r.getReadyCond(&nodes[i]); readyCond == nil || readyCond.Status != v1.ConditionTrue {
+			unavailableNodes[nodes[i].Name] = true
+		}
+	}
+
+	quorum := len(controlPlaneNodes)/2 + 1
+	if len(controlPlaneNodes)-1 < quorum {
+		// the quorum is lost whenever a control-plane node reboots, e.g. in clusters with one or two control-plane
+		// nodes, so holding the remediation doesn't protect it
+		return "", "", nil
+	}
+
+	for i := range snrs {
+		if !isRemediationActive(&snrs[i]) {
+			continue
+		}
+		nodeName := snrs[i].Name
+		if machineRef := getMachineOwnerRef(&snrs[i]); machineRef != nil {
+			machineNode, err := r.getNodeFromMachine(*machineRef, snrs[i].Namespace)
+			if err != nil {
+				// the node of the machine can't be found, so it doesn't take part in the quorum
+				continue
+			}
+			nodeName = machineNode.Name
+		}
+		if controlPlaneNodes[nodeName] {
+			unavailableNodes[nodeName] = true
+		}
+	}
+	delete(unavailableNodes, node.Name)
+
+	if availableNodes := len(controlPlaneNodes) - 1 - len(unavailableNodes); availableNodes < quorum {
+		return v1alpha1.EtcdQuorumGuardReason,
+			fmt.Sprintf("Remediation is held since only %d of %d control-plane nodes would be available while node %s reboots, which breaks the etcd quorum",
+				availableNodes, len(controlPlaneNodes), node.Name), nil
+	}
+	return "", "", nil
+}
+
+// getScheduleHoldReason returns the reason for holding remediations according to the remediation schedule of the
+// SelfNodeRemediationConfig. An empty reason means that remediations may start.
+func (r *SelfNodeRemediationReconciler) getScheduleHoldReason() (string, string, error) {
+	if r.ConfigNamespace == "" {
+		return "", "", nil
+	}
+
+	config := &v1alpha1.SelfNodeRemediationConfig{}
+	key := client.ObjectKey{Name: v1alpha1.ConfigCRName, Namespace: r.ConfigNamespace}
+	if err := r.Client.Get(context.Background(), key, config); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return "", "", nil
+		}
+		r.logger.Error(err, "failed to get SelfNodeRemediationConfig")
+		return "", "", err
+	}
+
+	held, reason, message, err := getRemediationScheduleState(config.Spec.RemediationSchedule, time.Now())
+	if err != nil {
+		r.logger.Error(err, "invalid remediation schedule")
+		return "", "", err
+	}
+	if !held {
+		return "", "", nil
+	}
+	return reason, message, nil
+}
+
+// getNodeMaintenanceReason returns the reason for deferring the remediation of the given node, if it's being updated
+// by the machine-config-operator or it's under a NodeMaintenance. An empty reason means that the remediation can continue.
+func (r *SelfNodeRemediationReconciler) getNodeMaintenanceReason(node *v1.Node) (string, string, error) {
+	if isMachineConfigUpdateInProgress(node) {
+		return v1alpha1.MachineConfigUpdateInProgressReason,
+			fmt.Sprintf("Remediation is deferred since node %s is being updated by the machine-config-operator", node.Name), nil
+	}
+
+	// use an unstructured list, so we don't need to depend on the node-maintenance-operator types
+	nodeMaintenances := &unstructured.UnstructuredList{}
+	nodeMaintenances.SetGroupVersionKind(schema.GroupVersionKind{Group: nodeMaintenanceGroup, Version: "v1beta1", Kind: "NodeMaintenanceList"})
+	if err := r.Client.List(context.Background(), nodeMaintenances); err != nil {
+		if meta.IsNoMatchError(err) {
+			// the node-maintenance-operator isn't installed
+			return "", "", nil
+		}
+		r.logger.Error(err, "failed to list NodeMaintenances")
+		return "", "", err
+	}
+
+	for _, nm := range nodeMaintenances.Items {
+		nodeName, _, err := unstructured.NestedString(nm.Object, "spec", "nodeName")
+		if err != nil {
+			r.logger.Error(err, "failed to parse nodeName of NodeMaintenance", "name", nm.GetName())
+			continue
+		}
+		if nodeName == node.Name && nm.GetDeletionTimestamp() == nil {
+			return v1alpha1.NodeUnderMaintenanceReason,
+				fmt.Sprintf("Remediation is deferred since node %s is under maintenance by NodeMaintenance %s", node.Name, nm.GetName()), nil
+		}
+	}
+	return "", "", nil
+}
+
+// isMachineConfigUpdateInProgress returns true if the machine-config-operator is updating the given node,
+// which includes draining and rebooting it
+func isMachineConfigUpdateInProgress(node *v1.Node) bool {
+	if node.Annotations[utils.MachineConfigStateAnnotation] == utils.MachineConfigStateWorking {
+		return true
+	}
+	currentConfig := node.Annotations[utils.MachineConfigCurrentConfigAnnotation]
+	desiredConfig := node.Annotations[utils.MachineConfigDesiredConfigAnnotation]
+	return currentConfig != "" && desiredConfig != "" && currentConfig != desiredConfig
+}
+
+// isRemediationActive returns true if the remediation of the given snr started and didn't finish yet
+func isRemediationActive(snr *v1alpha1.SelfNodeRemediation) bool {
+	if !controllerutil.ContainsFinalizer(snr, SNRFinalizer) {
+		return false
+	}
+	return snr.Status.Phase == nil || (*snr.Status.Phase != fencingCompletedPhase && *snr.Status.Phase != failedPhase)
+}
+
+// isRemediationEscalating returns true if the escalation steps of the given snr are running, before its finalizer is added
+func isRemediationEscalating(snr *v1alpha1.SelfNodeRemediation) bool {
+	if !snr.DeletionTimestamp.IsZero() {
+		return false
+	}
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.ProcessingConditionType)
+	return condition != nil && condition.Reason == v1alpha1.EscalatingReason
+}
+
+// isRemediationPending returns true if the remediation of the given snr didn't start yet, but it might start once other
+// remediations are done. Remediations which are held because of their own node, e.g. since it's under maintenance, or
+// which never start, e.g. dry runs, aren't pending
+func isRemediationPending(snr *v1alpha1.SelfNodeRemediation) bool {
+	if controllerutil.ContainsFinalizer(snr, SNRFinalizer) || !snr.DeletionTimestamp.IsZero() || snr.Spec.DryRun {
+		return false
+	}
+	if succeeded := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.SucceededConditionType); succeeded != nil && succeeded.Status != metav1.ConditionUnknown {
+		// the remediation is done or failed
+		return false
+	}
+	if meta.IsStatusConditionTrue(snr.Status.Conditions, v1alpha1.DisabledConditionType) {
+		return false
+	}
+	processing := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.ProcessingConditionType)
+	return processing == nil || (processing.Reason != v1alpha1.NodeUnderMaintenanceReason &&
+		processing.Reason != v1alpha1.MachineConfigUpdateInProgressReason && processing.Reason != v1alpha1.EscalatingReason)
+}
+
+// isCreatedBefore returns true if snr a was created before snr b. SNRs which were created at the same time are
+// ordered by their namespace and name, so that the order is the same for all agents
+func isCreatedBefore(a, b *v1alpha1.SelfNodeRemediation) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// queueRemediation updates the conditions of the queued snr, and checks periodically if it can start
+func (r *SelfNodeRemediationReconciler) queueRemediation(snr *v1alpha1.SelfNodeRemediation, reason string, message string) (ctrl.Result, error) {
+	r.logger.Info("remediation is queued", "reason", reason, "message", message)
+	patch := client.MergeFrom(snr.DeepCopy())
+	if setConditions(snr, reason) {
+		if err := r.Client.Status().Patch(context.Background(), snr, patch); err != nil {
+			r.logger.Error(err, "failed to update SelfNodeRemediation conditions", "reason", reason)
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(snr, eventTypeNormal, eventReasonRemediationQueued, message)
+	}
+	return ctrl.Result{RequeueAfter: queuedCheckInterval}, nil
+}
+
+// markRemediationFailed marks the snr as failed, so it isn't retried anymore
+func (r *SelfNodeRemediationReconciler) markRemediationFailed(snr *v1alpha1.SelfNodeRemediation, reason string, message string) (ctrl.Result, error) {
+	r.logger.Info("marking remediation as failed", "reason", reason, "message", message)
+	failed := failedPhase
+	snr.Status.Phase = &failed
+	setConditions(snr, reason)
+	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+		if apiErrors.IsConflict(err) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		r.logger.Error(err, "failed to mark SNR as failed")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(snr, eventTypeWarning, eventReasonRemediationFailed, message)
+	return ctrl.Result{}, nil
+}
+
+func isRemediationFailed(snr *v1alpha1.SelfNodeRemediation) bool {
+	return snr.Status.Phase != nil && *snr.Status.Phase == failedPhase
+}
+
+func isRemediationTimedOut(snr *v1alpha1.SelfNodeRemediation) bool {
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.SucceededConditionType)
+	return condition != nil && condition.Reason == v1alpha1.RemediationTimedOutReason
+}
+
+// getRemediationTimeout returns the remediation timeout of the snr, or the default one if the snr doesn't set it
+func (r *SelfNodeRemediationReconciler) getRemediationTimeout(snr *v1alpha1.SelfNodeRemediation) time.Duration {
+	if snr.Spec.RemediationTimeout != nil {
+		return snr.Spec.RemediationTimeout.Duration
+	}
+	return r.RemediationTimeout
+}
+
+// recordRemediation adds the start time of the given snr to the remediation history of the node, unless the
+// remediations limit within the remediations window is already reached. It returns false in the latter case
+func (r *SelfNodeRemediationReconciler) recordRemediation(node *v1.Node, snr *v1alpha1.SelfNodeRemediation) (bool, error) {
+	history, isRecorded := r.getRemediationHistory(node, snr)
+
+	// older remediations don't affect the limit anymore
+	if len(history) > r.MaxRemediationsPerNode {
+		history = history[len(history)-r.MaxRemediationsPerNode:]
+	}
+	if len(history) >= r.MaxRemediationsPerNode && !isRecorded {
+		return false, nil
+	}
+
+	history = append(history, snr.CreationTimestamp.UTC())
+	value := formatRemediationHistory(history)
+	if node.Annotations[utils.RemediationHistoryAnnotation] == value {
+		return true, nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[utils.RemediationHistoryAnnotation] = value
+	if err := r.Client.Patch(context.Background(), node, patch); err != nil {
+		r.logger.Error(err, "failed to update the remediation history of the node", "node name", node.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// getRemediationHistory returns the start times of the node's remediations within the remediations window, except
+// for the given snr, and whether the given snr is already recorded
+func (r *SelfNodeRemediationReconciler) getRemediationHistory(node *v1.Node, snr *v1alpha1.SelfNodeRemediation) ([]time.Time, bool) {
+	// the snr creation time identifies the remediation, so recording it again is a no-op
+	startTime := snr.CreationTimestamp.UTC()
+	isRecorded := false
+	var history []time.Time
+	for _, t := range parseRemediationHistory(node.Annotations[utils.RemediationHistoryAnnotation]) {
+		if t.Equal(startTime) {
+			isRecorded = true
+			continue
+		}
+		if r.RemediationsWindow == 0 || time.Since(t) <= r.RemediationsWindow {
+			history = append(history, t)
+		}
+	}
+	return history, isRecorded
+}
+
+func parseRemediationHistory(value string) []time.Time {
+	var history []time.Time
+	for _, entry := range strings.Split(value, ",") {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(entry)); err == nil {
+			history = append(history, t)
+		}
+	}
+	return history
+}
+
+func formatRemediationHistory(history []time.Time) string {
+	entries := make([]string, 0, len(history))
+	for _, t := range history {
+		entries = append(entries, t.Format(time.RFC3339))
+	}
+	return strings.Join(entries, ",")
+}
+
+// remediateDryRun goes through the decisions of the remediation without tainting, rebooting or deleting anything.
+// The actions which would have been taken are recorded in the status and as events
+func (r *SelfNodeRemediationReconciler) remediateDryRun(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	if isDryRunCompleted(snr) || !snr.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	node, err := r.getNodeFromSnr(snr)
+	if err != nil {
+		r.logger.Error(err, "failed to get node", "node name", snr.Name)
+		return ctrl.Result{}, err
+	}
+
+	if !r.isNodeRebootCapable(node) {
+		if err := r.updateConditions(snr, v1alpha1.NodeNotRebootCapableReason); err != nil {
+			return ctrl.Result{}, err
+		}
+		//use err to trigger exponential backoff
+		return ctrl.Result{}, errors.New("Node is not capable to reboot itself")
+	}
+
+	queuedReason, message, err := r.getNodeMaintenanceReason(node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if queuedReason == "" {
+		if queuedReason, message, err = r.getQueuedReason(snr, node); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if queuedReason != "" {
+		return r.queueRemediation(snr, queuedReason, message)
+	}
+
+	actions, err := r.getDryRunActions(snr, node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.logger.Info("dry run completed", "actions", actions)
+	snr.Status.DryRunActions = actions
+	setConditions(snr, v1alpha1.DryRunCompletedReason)
+	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+		if apiErrors.IsConflict(err) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		r.logger.Error(err, "failed to update status with the dry run actions")
+		return ctrl.Result{}, err
+	}
+
+	// events are emitted only by the agent which updated the status, so that they aren't duplicated
+	for _, action := range actions {
+		r.Recorder.Event(snr, eventTypeNormal, eventReasonDryRun, action)
+	}
+	return ctrl.Result{}, nil
+}
+
+// waitForApproval returns true if the remediation of the given snr was approved, or if the approval timed out and the
+// timeout action is "Approve". Otherwise, the returned result either keeps waiting or marks the remediation as failed
+func (r *SelfNodeRemediationReconciler) waitForApproval(snr *v1alpha1.SelfNodeRemediation) (bool, ctrl.Result, error) {
+	switch snr.Annotations[utils.RemediationApprovalAnnotation] {
+	case utils.RemediationApproved:
+		r.logger.Info("remediation was approved")
+		return true, ctrl.Result{}, nil
+	case utils.RemediationRejected:
+		result, err := r.markRemediationFailed(snr, v1alpha1.ApprovalRejectedReason,
+			fmt.Sprintf("Remediation of node %s was rejected", snr.Name))
+		return false, result, err
+	}
+
+	if snr.Status.ApprovalRequestedTime == nil {
+		now := metav1.Now()
+		snr.Status.ApprovalRequestedTime = &now
+		setConditions(snr, v1alpha1.AwaitingApprovalReason)
+		if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+			if apiErrors.IsConflict(err) {
+				return false, ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+			}
+			r.logger.Error(err, "failed to update status with the approval request")
+			return false, ctrl.Result{}, err
+		}
+		r.Recorder.Event(snr, eventTypeNormal, eventReasonAwaitingApproval,
+			fmt.Sprintf("Remediation of node %s is waiting for an approval", snr.Name))
+	}
+
+	policy := snr.Spec.ApprovalPolicy
+	if policy.Timeout == nil || policy.Timeout.Duration == 0 {
+		// approving or rejecting the remediation triggers a reconcile
+		r.logger.Info("waiting for an approval")
+		return false, ctrl.Result{}, nil
+	}
+
+	if timeLeft := time.Until(snr.Status.ApprovalRequestedTime.Add(policy.Timeout.Duration)); timeLeft > 0 {
+		r.logger.Info("waiting for an approval", "time left", timeLeft)
+		return false, ctrl.Result{RequeueAfter: timeLeft}, nil
+	}
+
+	if policy.TimeoutAction == v1alpha1.ApproveApprovalTimeoutAction {
+		r.logger.Info("approval timed out, continuing the remediation")
+		return true, ctrl.Result{}, nil
+	}
+	result, err := r.markRemediationFailed(snr, v1alpha1.ApprovalRejectedReason,
+		fmt.Sprintf("Remediation of node %s wasn't approved within %s", snr.Name, policy.Timeout.Duration))
+	return false, result, err
+}
+
+// IsRemediationOnHold returns true if the node of the given snr must not be rebooted, e.g. because the remediation
+// is a dry run, waits for an approval, executes the escalation steps or failed. Peers use it in order to not trigger the reboot of an isolated node.
+// Remediations which are held by the etcd quorum guard aren't on hold, since an isolated control-plane node
+// must still fence itself.
+func IsRemediationOnHold(snr *v1alpha1.SelfNodeRemediation) bool {
+	if isDryRun(snr) || isRemediationFailed(snr) {
+		return true
+	}
+
+	if snr.Spec.ApprovalPolicy != nil && snr.Status.TimeAssumedRebooted.IsZero() &&
+		snr.Annotations[utils.RemediationApprovalAnnotation] != utils.RemediationApproved {
+		return true
+	}
+
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.ProcessingConditionType)
+	if condition == nil {
+		return false
+	}
+	switch condition.Reason {
+	case v1alpha1.RemediationQueuedReason, v1alpha1.RemediationStormReason, v1alpha1.RemediationsPausedReason,
+		v1alpha1.BlackoutWindowReason, v1alpha1.OutsideMaintenanceWindowReason, v1alpha1.NodeUnderMaintenanceReason,
+		v1alpha1.MachineConfigUpdateInProgressReason, v1alpha1.EscalatingReason, v1alpha1.RecoveredByEscalationReason:
+		return true
+	}
+	return false
+}
+
+// isDryRun returns true if the given snr is a dry run. Setting dryRun on a remediation whose escalation steps or
+// fencing already started has no effect
+func isDryRun(snr *v1alpha1.SelfNodeRemediation) bool {
+	return snr.Spec.DryRun && !isRemediationStarted(snr)
+}
+
+// isRemediationStarted returns true if the escalation steps or the fencing of the given snr started
+func isRemediationStarted(snr *v1alpha1.SelfNodeRemediation) bool {
+	return controllerutil.ContainsFinalizer(snr, SNRFinalizer) || snr.Status.EscalationStep != nil
+}
+
+func isDryRunCompleted(snr *v1alpha1.SelfNodeRemediation) bool {
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.ProcessingConditionType)
+	return condition != nil && condition.Reason == v1alpha1.DryRunCompletedReason
+}
+
+// getDryRunActions returns the actions which the remediation of the given snr would take
+func (r *SelfNodeRemediationReconciler) getDryRunActions(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) ([]string, error) {
+	if r.MaxRemediationsPerNode > 0 {
+		if history, isRecorded := r.getRemediationHistory(node, snr); len(history) >= r.MaxRemediationsPerNode && !isRecorded {
+			return []string{fmt.Sprintf("Mark the remediation as failed, since node %s was already remediated %d times within %s",
+				node.Name, r.MaxRemediationsPerNode, r.RemediationsWindow)}, nil
+		}
+	}
+
+	var actions []string
+	steps := snr.Spec.EscalationSteps
+	fencingStep := 0
+	for ; !isFencingEscalationStep(steps, fencingStep); fencingStep++ {
+		actions = append(actions, fmt.Sprintf("Execute escalation step %d (%s) on node %s, and wait up to %s for the node to become ready",
+			fencingStep, steps[fencingStep].Action, node.Name, getEscalationStepTimeout(steps[fencingStep])))
+	}
+
+	actions = append(actions,
+		fmt.Sprintf("Taint node %s with the %s:%s taint", node.Name, NodeNoExecuteTaint.Key, NodeNoExecuteTaint.Effect),
+		fmt.Sprintf("Mark node %s as unschedulable", node.Name),
+	)
+	if snr.Spec.ApprovalPolicy != nil {
+		actions = append(actions, fmt.Sprintf("Wait for an approval to reboot node %s", node.Name))
+	}
+	powerAction := snr.Spec.PowerAction
+	if fencingStep < len(steps) && steps[fencingStep].Action == v1alpha1.PowerOffEscalationAction {
+		powerAction = v1alpha1.PowerOffPowerAction
+	}
+	switch powerAction {
+	case v1alpha1.PowerOffPowerAction:
+		actions = append(actions, fmt.Sprintf("Power off node %s and keep it down, and assume it's down after %s", node.Name, r.SafeTimeToAssumeNodeRebooted))
+	case v1alpha1.HaltPowerAction:
+		actions = append(actions, fmt.Sprintf("Halt node %s and keep it down, and assume it's down after %s", node.Name, r.SafeTimeToAssumeNodeRebooted))
+	default:
+		actions = append(actions, fmt.Sprintf("Reboot node %s, and assume it's rebooted after %s", node.Name, r.SafeTimeToAssumeNodeRebooted))
+	}
+
+	strategy := r.getRuntimeStrategy(snr)
+	switch strategy {
+	case v1alpha1.ResourceDeletionRemediationStrategy, v1alpha1.MachineDeletionRemediationStrategy:
+		var machineRef *metav1.OwnerReference
+		if strategy == v1alpha1.MachineDeletionRemediationStrategy {
+			if machineRef = getMachineOwnerRef(snr); machineRef == nil {
+				return nil, &UnreconcilableError{"MachineDeletion remediation strategy requires a SelfNodeRemediation which is owned by a machine"}
+			}
+		}
+		resourceActions, err := r.getResourceDeletionDryRunActions(node, snr.Spec.PodDeletionRules)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, resourceActions...)
+		if machineRef != nil {
+			actions = append(actions, fmt.Sprintf("Delete machine %s/%s", snr.Namespace, machineRef.Name))
+		}
+	case v1alpha1.OutOfServiceTaintRemediationStrategy:
+		actions = append(actions, fmt.Sprintf("Taint node %s with the %s:%s taint, and wait until its pods and volume attachments are deleted",
+			node.Name, OutOfServiceTaint.Key, OutOfServiceTaint.Effect))
+	case v1alpha1.NodeDeletionRemediationStrategy:
+		actions = append(actions, fmt.Sprintf("Delete node %s, and restore it from the node backup", node.Name))
+	}
+	return actions, nil
+}
+
+// getResourceDeletionDryRunActions returns the pod and volume attachment deletions of the "ResourceDeletion" strategy
+func (r *SelfNodeRemediationReconciler) getResourceDeletionDryRunActions(node *v1.Node, rules *v1alpha1.PodDeletionRules) ([]string, error) {
+	namespaces := v1.NamespaceList{}
+	if err := r.Client.List(context.Background(), &namespaces); err != nil {
+		r.logger.Error(err, "failed to list namespaces")
+		return nil, err
+	}
+
+	plan, err := r.planPodDeletion(node, namespaces.Items, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []string
+	for _, pod := range plan.forced {
+		actions = append(actions, fmt.Sprintf("Force delete pod %s/%s", pod.Namespace, pod.Name))
+	}
+	for _, pod := range plan.graceful {
+		actions = append(actions, fmt.Sprintf("Gracefully delete pod %s/%s", pod.Namespace, pod.Name))
+	}
+	for _, pod := range plan.skipped {
+		actions = append(actions, fmt.Sprintf("Skip deletion of pod %s/%s", pod.Namespace, pod.Name))
+	}
+
+	volumeAttachments := &storagev1.VolumeAttachmentList{}
+	if err := r.Client.List(context.Background(), volumeAttachments); err != nil {
+		r.logger.Error(err, "failed to get volumeAttachments list")
+		return nil, err
+	}
+	for _, va := range volumeAttachments.Items {
+		if va.Spec.NodeName == node.Name {
+			actions = append(actions, fmt.Sprintf("Force delete volume attachment %s", va.Name))
+		}
+	}
+	return actions, nil
+}
+
+func (r *SelfNodeRemediationReconciler) markFencingCompleted(snr *v1alpha1.SelfNodeRemediation, reason string) (ctrl.Result, error) {
+	fencingCompleted := fencingCompletedPhase
+	snr.Status.Phase = &fencingCompleted
+	setConditions(snr, reason)
+	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
+		if apiErrors.IsConflict(err) {
+			// conflicts are expected since all self node remediation deamonset pods are competing on the same requests
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		r.logger.Error(err, "failed to mark SNR as fencing completed")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// deleteResources returns a fencing function which deletes the pods and volume attachments of the unhealthy node.
+// All pods are force deleted, unless the given rules skip them or select them for graceful deletion
+func (r *SelfNodeRemediationReconciler) deleteResources(rules *v1alpha1.PodDeletionRules) fencingFunc {
+	return func(node *v1.Node) (bool, error) {
+		zero := int64(0)
+
+		namespaces := v1.NamespaceList{}
+		if err := r.Client.List(context.Background(), &namespaces); err != nil {
+			r.logger.Error(err, "failed to list namespaces", err)
+			return false, err
+		}
+
+		r.logger.Info("starting to delete node resources", "node name", node.Name)
+
+		if rules == nil {
+			if err := r.forceDeleteAllPods(node, namespaces.Items); err != nil {
+				return false, err
+			}
+		} else if err := r.deletePods(node, namespaces.Items, rules); err != nil {
+			return false, err
+		}
+
+		volumeAttachments := &storagev1.VolumeAttachmentList{}
+		if err := r.Client.List(context.Background(), volumeAttachments); err != nil {
+			r.logger.Error(err, "failed to get volumeAttachments list")
+			return false, err
+		}
+		forceDeleteOption := &client.DeleteOptions{
+			GracePeriodSeconds: &zero,
+		}
+		for _, va := range volumeAttachments.Items {
+			if va.Spec.NodeName == node.Name {
+				if err := r.Client.Delete(context.Background(), &va, forceDeleteOption); err != nil {
+					r.logger.Error(err, "failed to delete volumeAttachment", "name", va.Name)
+					return false, err
+				}
+			}
+		}
+
+		r.logger.Info("done deleting node resources", "node name", node.Name)
+		return true, nil
+	}
+}
+
+// forceDeleteAllPods force deletes all pods of the unhealthy node
+func (r *SelfNodeRemediationReconciler) forceDeleteAllPods(node *v1.Node, namespaces []v1.Namespace) error {
+	zero := int64(0)
+	backgroundDeletePolicy := metav1.DeletePropagationBackground
+
+	deleteOptions := &client.DeleteAllOfOptions{
+		ListOptions: client.ListOptions{
+			FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": node.Name}),
+			Namespace:     "",
+			Limit:         0,
+		},
+		DeleteOptions: client.DeleteOptions{
+			GracePeriodSeconds: &zero,
+			PropagationPolicy:  &backgroundDeletePolicy,
+		},
+	}
+
+	pod := &v1.Pod{}
+	for _, ns := range namespaces {
+		deleteOptions.Namespace = ns.Name
+		if err := r.Client.DeleteAllOf(context.Background(), pod, deleteOptions); err != nil {
+			r.logger.Error(err, "failed to delete pods of unhealthy node", "namespace", ns.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePods deletes the pods of the unhealthy node according to the given rules
+func (r *SelfNodeRemediationReconciler) deletePods(node *v1.Node, namespaces []v1.Namespace, rules *v1alpha1.PodDeletionRules) error {
+	plan, err := r.planPodDeletion(node, namespaces, rules)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range plan.skipped {
+		r.logger.Info("skipping pod deletion", "pod name", pod.Name, "namespace", pod.Namespace)
+	}
+
+	zero := int64(0)
+	backgroundDeletePolicy := metav1.DeletePropagationBackground
+	forceDeleteOptions := &client.DeleteOptions{GracePeriodSeconds: &zero, PropagationPolicy: &backgroundDeletePolicy}
+	gracefulDeleteOptions := &client.DeleteOptions{PropagationPolicy: &backgroundDeletePolicy}
+	for _, pod := range plan.forced {
+		if err := r.deletePod(pod, forceDeleteOptions); err != nil {
+			return err
+		}
+	}
+	for _, pod := range plan.graceful {
+		if err := r.deletePod(pod, gracefulDeleteOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SelfNodeRemediationReconciler) deletePod(pod *v1.Pod, deleteOptions *client.DeleteOptions) error {
+	if err := r.Client.Delete(context.Background(), pod, deleteOptions); err != nil && !apiErrors.IsNotFound(err) {
+		r.logger.Error(err, "failed to delete pod of unhealthy node", "pod name", pod.Name, "namespace", pod.Namespace)
+		return err
+	}
+	return nil
+}
+
+// podDeletionPlan groups the pods of the unhealthy node by the way they are deleted
+type podDeletionPlan struct {
+	forced   []*v1.Pod
+	graceful []*v1.Pod
+	skipped  []*v1.Pod
+}
 
-	if !r.isNodeRebootCapable(node) {
-		//use err to trigger exponential backoff
-		return ctrl.Result{}, errors.New("Node is not capable to reboot itself")
+// planPodDeletion returns the pods of the unhealthy node grouped according to the given rules.
+// When there are no rules, all pods are force deleted
+func (r *SelfNodeRemediationReconciler) planPodDeletion(node *v1.Node, namespaces []v1.Namespace, rules *v1alpha1.PodDeletionRules) (*podDeletionPlan, error) {
+	if rules == nil {
+		rules = &v1alpha1.PodDeletionRules{}
+	}
+	selectors, err := newPodDeletionSelectors(rules)
+	if err != nil {
+		r.logger.Error(err, "invalid pod deletion rules")
+		return nil, &UnreconcilableError{fmt.Sprintf("invalid pod deletion rules: %v", err)}
 	}
 
-	if !controllerutil.ContainsFinalizer(snr, SNRFinalizer) {
-		return r.addFinalizer(snr)
+	namespaceLabels := make(map[string]labels.Set, len(namespaces))
+	for _, ns := range namespaces {
+		namespaceLabels[ns.Name] = ns.Labels
 	}
 
-	if err = r.addNoExecuteTaint(node); err != nil {
-		return ctrl.Result{}, err
+	pods, err := r.listNodePods(node)
+	if err != nil {
+		return nil, err
 	}
 
-	if !node.Spec.Unschedulable || !utils.TaintExists(node.Spec.Taints, NodeUnschedulableTaint) {
-		return r.markNodeAsUnschedulable(node)
+	plan := &podDeletionPlan{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		nsLabels := namespaceLabels[pod.Namespace]
+		if selectors.isSkipped(pod, nsLabels) {
+			plan.skipped = append(plan.skipped, pod)
+		} else if selectors.isGraceful(pod, nsLabels) {
+			plan.graceful = append(plan.graceful, pod)
+		} else {
+			plan.forced = append(plan.forced, pod)
+		}
 	}
+	return plan, nil
+}
 
-	if snr.Status.TimeAssumedRebooted.IsZero() {
-		//todo this also updates the node but we don't need it
-		return r.updateSnrStatus(node, snr)
+// podDeletionSelectors are the parsed selectors of PodDeletionRules
+type podDeletionSelectors struct {
+	rules              *v1alpha1.PodDeletionRules
+	excludedPods       labels.Selector
+	excludedNamespaces labels.Selector
+	gracefulPods       labels.Selector
+	gracefulNamespaces labels.Selector
+}
+
+func newPodDeletionSelectors(rules *v1alpha1.PodDeletionRules) (*podDeletionSelectors, error) {
+	selectors := &podDeletionSelectors{rules: rules}
+	var err error
+	if selectors.excludedPods, err = toSelector(rules.ExcludedPods); err != nil {
+		return nil, err
 	}
+	if selectors.excludedNamespaces, err = toSelector(rules.ExcludedNamespaces); err != nil {
+		return nil, err
+	}
+	if selectors.gracefulPods, err = toSelector(rules.GracefulPods); err != nil {
+		return nil, err
+	}
+	if selectors.gracefulNamespaces, err = toSelector(rules.GracefulNamespaces); err != nil {
+		return nil, err
+	}
+	return selectors, nil
+}
 
-	if r.MyNodeName == node.Name {
-		// we have a problem on this node, reboot!
-		return r.rebootIfNeeded(snr)
+// toSelector converts the given label selector, a nil label selector matches nothing
+func toSelector(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Nothing(), nil
 	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
 
-	wasRebooted, timeLeft := r.wasNodeRebooted(snr)
-	if !wasRebooted {
-		return ctrl.Result{RequeueAfter: timeLeft}, nil
+func (s *podDeletionSelectors) isSkipped(pod *v1.Pod, namespaceLabels labels.Set) bool {
+	if s.rules.SkipStaticPods {
+		if _, isMirrorPod := pod.Annotations[v1.MirrorPodAnnotationKey]; isMirrorPod {
+			return true
+		}
+	}
+	if s.rules.SkipDaemonSetPods {
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+			return true
+		}
 	}
+	return s.excludedPods.Matches(labels.Set(pod.Labels)) || s.excludedNamespaces.Matches(namespaceLabels)
+}
 
-	r.logger.Info("TimeAssumedRebooted is old. The unhealthy node assumed to been rebooted", "node name", node.Name)
+func (s *podDeletionSelectors) isGraceful(pod *v1.Pod, namespaceLabels labels.Set) bool {
+	return s.gracefulPods.Matches(labels.Set(pod.Labels)) || s.gracefulNamespaces.Matches(namespaceLabels)
+}
 
-	//fence
-	zero := int64(0)
-	backgroundDeletePolicy := metav1.DeletePropagationBackground
+// deleteNode deletes the unhealthy node, fencing is completed only after the node is restored
+func (r *SelfNodeRemediationReconciler) deleteNode(node *v1.Node) (bool, error) {
+	r.logger.Info("deleting unhealthy node", "node name", node.Name)
+	if err := r.Client.Delete(context.Background(), node); err != nil && !apiErrors.IsNotFound(err) {
+		r.logger.Error(err, "failed to delete the unhealthy node", "node name", node.Name)
+		return false, err
+	}
+	return false, nil
+}
 
-	deleteOptions := &client.DeleteAllOfOptions{
-		ListOptions: client.ListOptions{
-			FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": node.Name}),
-			Namespace:     "",
-			Limit:         0,
-		},
-		DeleteOptions: client.DeleteOptions{
-			GracePeriodSeconds: &zero,
-			PropagationPolicy:  &backgroundDeletePolicy,
-		},
+// deleteResourcesAndMachine returns a fencing function which deletes the resources of the unhealthy node,
+// and then deletes its machine, so that a replacement is provisioned
+func (r *SelfNodeRemediationReconciler) deleteResourcesAndMachine(machineRef metav1.OwnerReference, ns string, rules *v1alpha1.PodDeletionRules) fencingFunc {
+	deleteResources := r.deleteResources(rules)
+	return func(node *v1.Node) (bool, error) {
+		if completed, err := deleteResources(node); err != nil || !completed {
+			return completed, err
+		}
+
+		machine := &unstructured.Unstructured{}
+		machine.SetAPIVersion(machineRef.APIVersion)
+		machine.SetKind(machineRef.Kind)
+		machine.SetName(machineRef.Name)
+		machine.SetNamespace(ns)
+		r.logger.Info("deleting unhealthy machine", "machine name", machineRef.Name, "node name", node.Name)
+		// the precondition prevents deleting a new machine with the same name, a conflict means that our machine is gone
+		preconditions := client.Preconditions{UID: &machineRef.UID}
+		if err := r.Client.Delete(context.Background(), machine, preconditions); err != nil && !apiErrors.IsNotFound(err) && !apiErrors.IsConflict(err) {
+			r.logger.Error(err, "failed to delete the unhealthy machine", "machine name", machineRef.Name)
+			return false, err
+		}
+		return true, nil
 	}
+}
 
-	namespaces := v1.NamespaceList{}
-	if err := r.Client.List(context.Background(), &namespaces); err != nil {
-		r.logger.Error(err, "failed to list namespaces", err)
-		return ctrl.Result{}, err
+// fenceWithOutOfServiceTaint adds the out-of-service taint to the unhealthy node, and waits until kubernetes
+// deleted its terminating pods and volume attachments
+func (r *SelfNodeRemediationReconciler) fenceWithOutOfServiceTaint(node *v1.Node) (bool, error) {
+	if err := r.addOutOfServiceTaint(node); err != nil {
+		return false, err
 	}
 
-	r.logger.Info("starting to delete node resources", "node name", node.Name)
+	return r.isResourceDeletionCompleted(node)
+}
 
-	pod := &v1.Pod{}
-	for _, ns := range namespaces.Items {
-		deleteOptions.Namespace = ns.Name
-		err = r.Client.DeleteAllOf(context.Background(), pod, deleteOptions)
-		if err != nil {
-			r.logger.Error(err, "failed to delete pods of unhealthy node", "namespace", ns.Name)
-			return ctrl.Result{}, err
+// isResourceDeletionCompleted returns true if the unhealthy node has no terminating pods and no volume attachments
+func (r *SelfNodeRemediationReconciler) isResourceDeletionCompleted(node *v1.Node) (bool, error) {
+	pods, err := r.listNodePods(node)
+	if err != nil {
+		return false, err
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			r.logger.Info("waiting for terminating pod to be deleted", "pod name", pod.Name, "namespace", pod.Namespace, "node name", node.Name)
+			return false, nil
 		}
 	}
 
 	volumeAttachments := &storagev1.VolumeAttachmentList{}
 	if err := r.Client.List(context.Background(), volumeAttachments); err != nil {
 		r.logger.Error(err, "failed to get volumeAttachments list")
-		return ctrl.Result{}, err
-	}
-	forceDeleteOption := &client.DeleteOptions{
-		GracePeriodSeconds: &zero,
+		return false, err
 	}
 	for _, va := range volumeAttachments.Items {
 		if va.Spec.NodeName == node.Name {
-			err = r.Client.Delete(context.Background(), &va, forceDeleteOption)
-			if err != nil {
-				r.logger.Error(err, "failed to delete volumeAttachment", "name", va.Name)
-				return ctrl.Result{}, err
-			}
+			r.logger.Info("waiting for volume attachment to be deleted", "name", va.Name, "node name", node.Name)
+			return false, nil
 		}
 	}
 
-	r.logger.Info("done deleting node resources", "node name", node.Name)
+	r.logger.Info("out-of-service taint resource deletion completed", "node name", node.Name)
+	return true, nil
+}
 
-	fencingCompleted := fencingCompletedPhase
-	snr.Status.Phase = &fencingCompleted
-	if err := r.Client.Status().Update(context.Background(), snr); err != nil {
-		if apiErrors.IsConflict(err) {
-			// conflicts are expected since all self node remediation deamonset pods are competing on the same requests
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-		}
-		r.logger.Error(err, "failed to mark SNR as fencing completed")
-		return ctrl.Result{}, err
+// listNodePods lists the pods of the given node. The pods are listed from the api server by a field selector, so
+// that the agents don't cache the pods of the whole cluster
+func (r *SelfNodeRemediationReconciler) listNodePods(node *v1.Node) (*v1.PodList, error) {
+	pods := &v1.PodList{}
+	if err := r.APIReader.List(context.Background(), pods, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
+		r.logger.Error(err, "failed to get pod list", "node name", node.Name)
+		return nil, err
 	}
-
-	return ctrl.Result{}, nil
+	return pods, nil
 }
 
-// rebootIfNeeded reboots the node if no reboot was performed so far
+// rebootIfNeeded reboots the node if no reboot was performed so far, or takes it down again if it must be kept down
 func (r *SelfNodeRemediationReconciler) rebootIfNeeded(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	r.PostMortem.SetRemediation(snr.Namespace, snr.Name)
+
+	// a node which must be kept down is taken down again, e.g. when it was rebooted since it couldn't access the api server
+	switch getPowerAction(snr) {
+	case v1alpha1.PowerOffPowerAction:
+		r.logger.Info("powering off the node, it's kept down until an operator intervenes")
+		r.writeRebootMarker(snr, "Node is powered off by its remediation")
+		return ctrl.Result{RequeueAfter: reboot.TimeToAssumeRebootHasStarted}, r.Rebooter.PowerOff()
+	case v1alpha1.HaltPowerAction:
+		r.logger.Info("halting the node, it's kept down until an operator intervenes")
+		r.writeRebootMarker(snr, "Node is halted by its remediation")
+		return ctrl.Result{RequeueAfter: reboot.TimeToAssumeRebootHasStarted}, r.Rebooter.Halt()
+	}
+
 	shouldAvoidReboot, err := r.didIRebootMyself(snr)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -323,24 +1663,140 @@ func (r *SelfNodeRemediationReconciler) rebootIfNeeded(snr *v1alpha1.SelfNodeRem
 		return ctrl.Result{}, nil
 	}
 
+	r.writeRebootMarker(snr, "Node is rebooted by its remediation")
 	return ctrl.Result{RequeueAfter: reboot.TimeToAssumeRebootHasStarted}, r.Rebooter.Reboot()
 }
 
-// wasNodeRebooted returns true if the node assumed to been rebooted.
-// if not, it will also return the remaining time for that to happen
-func (r *SelfNodeRemediationReconciler) wasNodeRebooted(snr *v1alpha1.SelfNodeRemediation) (bool, time.Duration) {
+// writeRebootMarker writes the reboot marker with the given snr and reason, so that the agent reports them after the
+// reboot. A failure doesn't prevent the reboot
+func (r *SelfNodeRemediationReconciler) writeRebootMarker(snr *v1alpha1.SelfNodeRemediation, reason string) {
+	rebootReason := postmortem.RebootReason{RemediationNamespace: snr.Namespace, RemediationName: snr.Name,
+		RemediationUID: snr.UID, Reason: reason}
+	if err := r.RebootMarker.Write(rebootReason); err != nil {
+		r.logger.Error(err, "failed to write the reboot marker")
+	}
+}
+
+// wasNodeRebooted returns true if the node was rebooted, which is proven by a changed boot ID, or assumed to been
+// rebooted. if not, it will also return the remaining time for that to happen
+func (r *SelfNodeRemediationReconciler) wasNodeRebooted(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) (bool, time.Duration) {
+	if bootID := r.getNodeStatusBootID(node); isBootIDChanged(snr, bootID) {
+		r.logger.Info("The unhealthy node's boot ID changed, it was rebooted", "node name", node.Name,
+			"previous boot ID", snr.Status.NodeBootID, "boot ID", bootID)
+		return true, 0
+	}
+
 	maxNodeRebootTime := snr.Status.TimeAssumedRebooted
 
 	if maxNodeRebootTime.After(time.Now()) {
 		return false, maxNodeRebootTime.Sub(time.Now()) + time.Second
 	}
 
+	r.logger.Info("TimeAssumedRebooted is old. The unhealthy node assumed to been rebooted", "node name", node.Name)
 	return true, 0
 }
 
-// didIRebootMyself returns true if system uptime is less than the time from SNR creation timestamp
-// which means that the host was already rebooted (at least) once during this SNR lifecycle
+// isRebootConfirmed probes the unhealthy node, and returns true if it was rebooted or is unreachable. Otherwise, it
+// also returns why the reboot isn't confirmed
+func (r *SelfNodeRemediationReconciler) isRebootConfirmed(snr *v1alpha1.SelfNodeRemediation, node *v1.Node) (bool, string) {
+	if r.NodeProber == nil {
+		return true, ""
+	}
+
+	bootID, uptime, err := r.NodeProber.GetBootInfo(node)
+	if err == nil {
+		// remediations which didn't record the boot ID compare the uptime with the time since the SNR creation
+		wasRebooted := uptime < time.Since(snr.CreationTimestamp.Time)
+		if snr.Status.NodeBootID != "" {
+			wasRebooted = isBootIDChanged(snr, bootID)
+		}
+		if !wasRebooted {
+			return false, fmt.Sprintf("Node %s still answers with boot ID %s and uptime %s, it wasn't rebooted", node.Name, bootID, uptime)
+		}
+		r.logger.Info("the unhealthy node answers after it was rebooted", "node name", node.Name, "boot ID", bootID, "uptime", uptime)
+		return true, ""
+	}
+
+	r.logger.Info("the unhealthy node doesn't answer its peer health port", "node name", node.Name, "error", err.Error())
+	if r.NodeProber.IsReachable(node) {
+		return false, fmt.Sprintf("Node %s doesn't answer its peer health port, but it replies to pings", node.Name)
+	}
+	return true, ""
+}
+
+// getHostBootID returns the boot ID which the host of the given node reports itself, since the boot ID in the node
+// status might be stale, e.g. when the node was rebooted while its kubelet didn't update the status. When the host
+// can't be asked, it falls back to the boot ID in the status of a ready node. An empty boot ID is returned when
+// neither is available, in which case the remediation relies on TimeAssumedRebooted and on the uptime of the node
+func (r *SelfNodeRemediationReconciler) getHostBootID(node *v1.Node) string {
+	if r.MyNodeName == node.Name {
+		bootID, err := utils.GetLinuxBootID()
+		if err == nil {
+			return bootID
+		}
+		r.logger.Error(err, "failed to get node's boot ID")
+	} else if r.NodeProber != nil {
+		bootID, _, err := r.NodeProber.GetBootInfo(node)
+		if err == nil {
+			return bootID
+		}
+		r.logger.Info("failed to get the boot ID from the unhealthy node", "node name", node.Name, "error", err.Error())
+	}
+	return r.getNodeStatusBootID(node)
+}
+
+// getNodeStatusBootID returns the boot ID in the status of the given node if it's ready, and an empty boot ID
+// otherwise, since the kubelet of a node which isn't ready might not have updated it since the node was rebooted
+func (r *SelfNodeRemediationReconciler) getNodeStatusBootID(node *v1.Node) string {
+	if readyCond := r.getReadyCond(node); readyCond == nil || readyCond.Status != v1.ConditionTrue {
+		return ""
+	}
+	return node.Status.NodeInfo.BootID
+}
+
+// postponeFencing postpones fencing the node until its reboot is confirmed, and marks the remediation as failed once
+// MaxFencingPostponement passed since TimeAssumedRebooted
+func (r *SelfNodeRemediationReconciler) postponeFencing(snr *v1alpha1.SelfNodeRemediation, node *v1.Node, message string) (ctrl.Result, error) {
+	if r.MaxFencingPostponement > 0 && time.Now().After(snr.Status.TimeAssumedRebooted.Add(r.MaxFencingPostponement)) {
+		return r.markRemediationFailed(snr, v1alpha1.RebootNotConfirmedReason,
+			fmt.Sprintf("%s, and fencing was postponed for %s", message, r.MaxFencingPostponement))
+	}
+
+	r.logger.Info("fencing is postponed", "node name", node.Name, "reason", message)
+	if !isFencingPostponed(snr) {
+		if err := r.updateConditions(snr, v1alpha1.FencingPostponedReason); err != nil {
+			return ctrl.Result{}, err
+		}
+		// the event is emitted once, rather than on every check
+		r.Recorder.Event(snr, eventTypeWarning, eventReasonFencingPostponed, message)
+	}
+	return ctrl.Result{RequeueAfter: fencingCheckInterval}, nil
+}
+
+func isFencingPostponed(snr *v1alpha1.SelfNodeRemediation) bool {
+	condition := meta.FindStatusCondition(snr.Status.Conditions, v1alpha1.SucceededConditionType)
+	return condition != nil && condition.Reason == v1alpha1.FencingPostponedReason
+}
+
+// isBootIDChanged returns true if the given boot ID differs from the one which was recorded when the reboot started
+func isBootIDChanged(snr *v1alpha1.SelfNodeRemediation, bootID string) bool {
+	return snr.Status.NodeBootID != "" && bootID != "" && bootID != snr.Status.NodeBootID
+}
+
+// didIRebootMyself returns true if the host's boot ID differs from the one which was recorded when the reboot
+// started, which means that the host was already rebooted (at least) once during this SNR lifecycle.
+// Remediations which didn't record the boot ID fall back to comparing the system uptime with the time since the SNR
+// creation timestamp
 func (r *SelfNodeRemediationReconciler) didIRebootMyself(snr *v1alpha1.SelfNodeRemediation) (bool, error) {
+	if snr.Status.NodeBootID != "" {
+		bootID, err := utils.GetLinuxBootID()
+		if err != nil {
+			r.logger.Error(err, "failed to get node's boot ID")
+			return false, err
+		}
+		return isBootIDChanged(snr, bootID), nil
+	}
+
 	uptime, err := utils.GetLinuxUptime()
 	if err != nil {
 		r.logger.Error(err, "failed to get node's uptime")
@@ -407,9 +1863,67 @@ func (r *SelfNodeRemediationReconciler) addFinalizer(snr *v1alpha1.SelfNodeRemed
 		return ctrl.Result{}, err
 	}
 	r.logger.Info("finalizer added")
+
+	if err := r.updateConditions(snr, v1alpha1.FinalizerAddedReason); err != nil {
+		return ctrl.Result{}, err
+	}
 	return ctrl.Result{Requeue: true}, nil
 }
 
+// updateConditions sets the conditions of the given remediation step, and patches the snr status if they changed
+func (r *SelfNodeRemediationReconciler) updateConditions(snr *v1alpha1.SelfNodeRemediation, reason string) error {
+	patch := client.MergeFrom(snr.DeepCopy())
+	if !setConditions(snr, reason) {
+		return nil
+	}
+
+	if err := r.Client.Status().Patch(context.Background(), snr, patch); err != nil {
+		r.logger.Error(err, "failed to update SelfNodeRemediation conditions", "reason", reason)
+		return err
+	}
+	return nil
+}
+
+// setConditions sets the conditions of the given remediation step, and returns true if they changed
+func setConditions(snr *v1alpha1.SelfNodeRemediation, reason string) bool {
+	processing, succeeded, disabled := metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse
+	switch reason {
+	case v1alpha1.NodeNotRebootCapableReason:
+		processing, succeeded, disabled = metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionTrue
+	case v1alpha1.FencingCompletedReason, v1alpha1.NodeRestoredReason, v1alpha1.RecoveredByEscalationReason,
+		v1alpha1.NodeKeptDownReason:
+		processing, succeeded = metav1.ConditionFalse, metav1.ConditionTrue
+	case v1alpha1.RemediationTimedOutReason, v1alpha1.TooManyRemediationsReason, v1alpha1.ApprovalRejectedReason,
+		v1alpha1.RebootNotConfirmedReason:
+		processing, succeeded = metav1.ConditionFalse, metav1.ConditionFalse
+	case v1alpha1.RemediationQueuedReason, v1alpha1.RemediationStormReason, v1alpha1.DryRunCompletedReason,
+		v1alpha1.RemediationsPausedReason, v1alpha1.BlackoutWindowReason, v1alpha1.OutsideMaintenanceWindowReason,
+		v1alpha1.NodeUnderMaintenanceReason, v1alpha1.MachineConfigUpdateInProgressReason, v1alpha1.EtcdQuorumGuardReason:
+		processing = metav1.ConditionFalse
+	}
+
+	message := conditionMessages[reason]
+	changed := setCondition(&snr.Status.Conditions, v1alpha1.ProcessingConditionType, processing, reason, message)
+	changed = setCondition(&snr.Status.Conditions, v1alpha1.SucceededConditionType, succeeded, reason, message) || changed
+	changed = setCondition(&snr.Status.Conditions, v1alpha1.DisabledConditionType, disabled, reason, message) || changed
+	return changed
+}
+
+func setCondition(conditions *[]metav1.Condition, conditionType string, status metav1.ConditionStatus, reason string, message string) bool {
+	if existing := meta.FindStatusCondition(*conditions, conditionType); existing != nil &&
+		existing.Status == status && existing.Reason == reason && existing.Message == message {
+		return false
+	}
+
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	return true
+}
+
 // returns the lastHeartbeatTime of the first condition, if exists. Otherwise returns the zero value
 func (r *SelfNodeRemediationReconciler) getLastHeartbeatTime(node *v1.Node) time.Time {
 	var lastHeartbeat metav1.Time
@@ -433,7 +1947,12 @@ func (r *SelfNodeRemediationReconciler) updateSnrStatus(node *v1.Node, snr *v1al
 	//we assume the unhealthy node will be rebooted by maxTimeNodeHasRebooted
 	maxTimeNodeHasRebooted := metav1.NewTime(metav1.Now().Add(r.SafeTimeToAssumeNodeRebooted))
 	snr.Status.TimeAssumedRebooted = &maxTimeNodeHasRebooted
+	// the remediation starts once it passed the queue and the approval
+	now := metav1.Now()
+	snr.Status.RemediationStartTime = &now
 	snr.Status.NodeBackup = node
+	// a changed boot ID proves that the node was rebooted before TimeAssumedRebooted
+	snr.Status.NodeBootID = r.getHostBootID(node)
 
 	err := r.Client.Status().Update(context.Background(), snr)
 	if err != nil {
@@ -453,15 +1972,17 @@ func (r *SelfNodeRemediationReconciler) getNodeFromSnr(snr *v1alpha1.SelfNodeRem
 	//by a node based controller (e.g. NHC). This assumes that machine based controller
 	//will create the snr with machine owner reference
 
-	for _, ownerRef := range snr.OwnerReferences {
-		if ownerRef.Kind == "Machine" {
-			r.mutex.Lock()
-			wasLastSeenSnrMachine = true
-			r.mutex.Unlock()
-			return r.getNodeFromMachine(ownerRef, snr.Namespace)
-		}
+	if machineRef := getMachineOwnerRef(snr); machineRef != nil {
+		r.mutex.Lock()
+		wasLastSeenSnrMachine = true
+		r.mutex.Unlock()
+		return r.getNodeFromMachine(*machineRef, snr.Namespace)
 	}
 
+	r.mutex.Lock()
+	wasLastSeenSnrMachine = false
+	r.mutex.Unlock()
+
 	//since we didn't find a machine owner ref, we assume that snr name is the unhealthy node name
 	node := &v1.Node{}
 	key := client.ObjectKey{
@@ -476,40 +1997,102 @@ func (r *SelfNodeRemediationReconciler) getNodeFromSnr(snr *v1alpha1.SelfNodeRem
 	return node, nil
 }
 
+// getMachineOwnerRef returns the machine owner ref of the given snr, or nil if it isn't owned by a machine
+func getMachineOwnerRef(snr *v1alpha1.SelfNodeRemediation) *metav1.OwnerReference {
+	for i := range snr.OwnerReferences {
+		if snr.OwnerReferences[i].Kind == "Machine" {
+			return &snr.OwnerReferences[i]
+		}
+	}
+	return nil
+}
+
+// getNodeFromMachine returns the node of the given machine owner ref, which can be either an
+// OpenShift Machine API machine or a Cluster API machine
 func (r *SelfNodeRemediationReconciler) getNodeFromMachine(ref metav1.OwnerReference, ns string) (*v1.Node, error) {
-	machine := &machinev1beta1.Machine{}
 	machineKey := client.ObjectKey{
 		Name:      ref.Name,
 		Namespace: ns,
 	}
 
-	if err := r.Client.Get(context.Background(), machineKey, machine); err != nil {
-		r.logger.Error(err, "failed to get machine from SelfNodeRemediation CR owner ref",
-			"machine name", machineKey.Name, "namespace", machineKey.Namespace)
-		return nil, err
+	var nodeName string
+	var err error
+	if isCapiMachine(ref) {
+		nodeName, err = r.getCapiMachineNodeName(ref, machineKey)
+	} else {
+		nodeName, err = r.getOpenshiftMachineNodeName(machineKey)
 	}
-
-	if machine.Status.NodeRef == nil {
-		err := errors.New("nodeRef is nil")
-		r.logger.Error(err, "failed to retrieve node from the unhealthy machine")
+	if err != nil {
 		return nil, err
 	}
 
 	node := &v1.Node{}
 	key := client.ObjectKey{
-		Name:      machine.Status.NodeRef.Name,
-		Namespace: machine.Status.NodeRef.Namespace,
+		Name:      nodeName,
+		Namespace: "",
 	}
 
 	if err := r.Get(context.Background(), key, node); err != nil {
 		r.logger.Error(err, "failed to retrieve node from the unhealthy machine",
-			"node name", node.Name, "machine name", machine.Name)
+			"node name", nodeName, "machine name", machineKey.Name)
 		return nil, err
 	}
 
 	return node, nil
 }
 
+func (r *SelfNodeRemediationReconciler) getOpenshiftMachineNodeName(machineKey client.ObjectKey) (string, error) {
+	machine := &machinev1beta1.Machine{}
+	if err := r.Client.Get(context.Background(), machineKey, machine); err != nil {
+		r.logger.Error(err, "failed to get machine from SelfNodeRemediation CR owner ref",
+			"machine name", machineKey.Name, "namespace", machineKey.Namespace)
+		return "", err
+	}
+
+	if machine.Status.NodeRef == nil {
+		err := errors.New("nodeRef is nil")
+		r.logger.Error(err, "failed to retrieve node from the unhealthy machine")
+		return "", err
+	}
+
+	return machine.Status.NodeRef.Name, nil
+}
+
+// getCapiMachineNodeName uses an unstructured object for Cluster API machines, so we don't need to depend
+// on the Cluster API types
+func (r *SelfNodeRemediationReconciler) getCapiMachineNodeName(ref metav1.OwnerReference, machineKey client.ObjectKey) (string, error) {
+	machine := &unstructured.Unstructured{}
+	machine.SetAPIVersion(ref.APIVersion)
+	machine.SetKind(ref.Kind)
+	if err := r.Client.Get(context.Background(), machineKey, machine); err != nil {
+		r.logger.Error(err, "failed to get cluster api machine from SelfNodeRemediation CR owner ref",
+			"machine name", machineKey.Name, "namespace", machineKey.Namespace)
+		return "", err
+	}
+
+	nodeName, found, err := unstructured.NestedString(machine.Object, "status", "nodeRef", "name")
+	if err != nil {
+		r.logger.Error(err, "failed to parse nodeRef of the unhealthy cluster api machine", "machine name", machineKey.Name)
+		return "", err
+	}
+	if !found || nodeName == "" {
+		err := errors.New("nodeRef is nil")
+		r.logger.Error(err, "failed to retrieve node from the unhealthy machine")
+		return "", err
+	}
+
+	return nodeName, nil
+}
+
+// isCapiMachine returns true if the owner ref points to a Cluster API machine
+func isCapiMachine(ref metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	return gv.Group == capiMachineGroup
+}
+
 // the unhealthy node might reboot itself and take new workloads
 // since we're going to delete the node eventually, we must make sure the node is deleted only
 // when there's no running workload there. Hence we mark it as unschedulable.
@@ -549,14 +2132,14 @@ func (r *SelfNodeRemediationReconciler) handleDeletedNode(snr *v1alpha1.SelfNode
 		return ctrl.Result{RequeueAfter: minRestoreNodeTime.Sub(time.Now()) + time.Second}, nil
 	}
 
-	return r.restoreNode(snr.Status.NodeBackup)
+	return r.restoreNode(snr)
 }
 
-func (r *SelfNodeRemediationReconciler) restoreNode(nodeToRestore *v1.Node) (ctrl.Result, error) {
+func (r *SelfNodeRemediationReconciler) restoreNode(snr *v1alpha1.SelfNodeRemediation) (ctrl.Result, error) {
+	nodeToRestore := snr.Status.NodeBackup.DeepCopy()
 	r.logger.Info("restoring node", "node name", nodeToRestore.Name)
 
-	// todo we probably want to have some allowlist/denylist on which things to restore, we already had
-	// a problem when we restored ovn annotations
+	filterNodeToRestore(nodeToRestore, snr.Spec.NodeRestoreRules)
 	nodeToRestore.ResourceVersion = "" //create won't work with a non-empty value here
 	taints, _ := utils.DeleteTaint(nodeToRestore.Spec.Taints, NodeUnschedulableTaint)
 	nodeToRestore.Spec.Taints = taints
@@ -565,18 +2148,61 @@ func (r *SelfNodeRemediationReconciler) restoreNode(nodeToRestore *v1.Node) (ctr
 	nodeToRestore.Status = v1.NodeStatus{}
 
 	if err := r.Client.Create(context.TODO(), nodeToRestore); err != nil {
-		if apiErrors.IsAlreadyExists(err) {
-			// node is already created, either by another agent or by the cluster
-			r.logger.Info("failed to create node since it already exist", "node name", nodeToRestore.Name)
-			return ctrl.Result{}, nil
+		if !apiErrors.IsAlreadyExists(err) {
+			r.logger.Error(err, "failed to create node", "node name", nodeToRestore.Name)
+			return ctrl.Result{}, err
 		}
-		r.logger.Error(err, "failed to create node", "node name", nodeToRestore.Name)
-		return ctrl.Result{}, err
+		// node is already created, either by another agent or by the cluster
+		r.logger.Info("failed to create node since it already exist", "node name", nodeToRestore.Name)
+	} else {
+		r.logger.Info("node restored successfully", "node name", nodeToRestore.Name)
 	}
 
-	r.logger.Info("node restored successfully", "node name", nodeToRestore.Name)
-	// all done, stop reconciling
-	return ctrl.Result{Requeue: true}, nil
+	return r.markFencingCompleted(snr, v1alpha1.NodeRestoredReason)
+}
+
+// filterNodeToRestore removes the labels, annotations and taints which shouldn't be restored according to the given rules
+func filterNodeToRestore(node *v1.Node, rules *v1alpha1.NodeRestoreRules) {
+	if rules == nil {
+		rules = &v1alpha1.NodeRestoreRules{}
+	}
+
+	// the default denylist is always applied, since the denied annotations break the networking of the new node
+	annotationsFilter := defaultAnnotationsRestoreFilter
+	if rules.Annotations != nil {
+		annotationsFilter = &v1alpha1.RestoreFilter{
+			Allowlist: rules.Annotations.Allowlist,
+			Denylist:  append(append([]string{}, defaultAnnotationsRestoreFilter.Denylist...), rules.Annotations.Denylist...),
+		}
+	}
+
+	node.Labels = filterMap(node.Labels, rules.Labels)
+	node.Annotations = filterMap(node.Annotations, annotationsFilter)
+
+	if rules.Taints != nil {
+		taints := []v1.Taint{}
+		for _, taint := range node.Spec.Taints {
+			// the remediation taint is always restored, it's removed when remediation is done
+			if taint.MatchTaint(NodeNoExecuteTaint) || utils.IsKeyAllowed(taint.Key, rules.Taints.Allowlist, rules.Taints.Denylist) {
+				taints = append(taints, taint)
+			}
+		}
+		node.Spec.Taints = taints
+	}
+}
+
+func filterMap(m map[string]string, filter *v1alpha1.RestoreFilter) map[string]string {
+	if filter == nil || m == nil {
+		return m
+	}
+
+	filtered := map[string]string{}
+	for key, val := range m {
+		if utils.IsKeyAllowed(key, filter.Allowlist, filter.Denylist) {
+			filtered[key] = val
+		}
+	}
+	return filtered
 }
 
 func (r *SelfNodeRemediationReconciler) updateSnrStatusLastError(snr *v1alpha1.SelfNodeRemediation, err error) error {
@@ -646,3 +2272,37 @@ func (r *SelfNodeRemediationReconciler) removeNoExecuteTaint(node *v1.Node) erro
 	r.logger.Info("NoExecute taint removed", "new taints", node.Spec.Taints)
 	return nil
 }
+
+func (r *SelfNodeRemediationReconciler) addOutOfServiceTaint(node *v1.Node) error {
+	if utils.TaintExists(node.Spec.Taints, OutOfServiceTaint) {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	taint := *OutOfServiceTaint
+	now := metav1.Now()
+	taint.TimeAdded = &now
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+	if err := r.Client.Patch(context.Background(), node, patch); err != nil {
+		r.logger.Error(err, "Failed to add out-of-service taint on node", "node name", node.Name)
+		return err
+	}
+	r.logger.Info("out-of-service taint added", "new taints", node.Spec.Taints)
+	return nil
+}
+
+func (r *SelfNodeRemediationReconciler) removeOutOfServiceTaint(node *v1.Node) error {
+	if !utils.TaintExists(node.Spec.Taints, OutOfServiceTaint) {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	taints, _ := utils.DeleteTaint(node.Spec.Taints, OutOfServiceTaint)
+	node.Spec.Taints = taints
+	if err := r.Client.Patch(context.Background(), node, patch); err != nil {
+		r.logger.Error(err, "Failed to remove out-of-service taint from node", "node name", node.Name)
+		return err
+	}
+	r.logger.Info("out-of-service taint removed", "new taints", node.Spec.Taints)
+	return nil
+}
@@ -2,15 +2,19 @@ package controllers_test
 
 import (
 	"context"
+	"fmt"
 	"github.com/medik8s/self-node-remediation/controllers"
 	"github.com/medik8s/self-node-remediation/pkg/utils"
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -23,6 +27,7 @@ import (
 
 const (
 	snrNamespace = "default"
+	machineName  = "unhealthy-machine"
 )
 
 var _ = Describe("snr Controller", func() {
@@ -81,99 +86,1250 @@ var _ = Describe("snr Controller", func() {
 			})
 		})
 
+		Context("remediation timeout", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			JustBeforeEach(func() {
+				updateSnrFunc := func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+					snr.Spec.RemediationTimeout = &metav1.Duration{Duration: time.Second}
+				}
+				eventuallyUpdateSNR(updateSnrFunc)
+			})
+
+			It("snr shouldn't time out before the remediation started", func() {
+				Consistently(func() (bool, error) {
+					snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+					if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}, snr); err != nil {
+						return false, err
+					}
+					return meta.IsStatusConditionPresentAndEqual(snr.Status.Conditions, selfnoderemediationv1alpha1.SucceededConditionType, metav1.ConditionFalse), nil
+				}, 5*time.Second, 250*time.Millisecond).Should(BeFalse())
+			})
+		})
+
+	})
+
+	Context("Unhealthy machine", func() {
+		var machineAPIVersion string
+		var remediationStrategy selfnoderemediationv1alpha1.RemediationStrategyType
+		var machineSNR *selfnoderemediationv1alpha1.SelfNodeRemediation
+		var isSNRNeedsDeletion bool
+
+		BeforeEach(func() {
+			remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			isSNRNeedsDeletion = true
+		})
+
+		JustBeforeEach(func() {
+			createMachine(machineAPIVersion)
+			machineSNR = createMachineSNR(machineAPIVersion, remediationStrategy)
+		})
+
+		AfterEach(func() {
+			if isSNRNeedsDeletion {
+				deleteSNR(machineSNR)
+			}
+			deleteMachine(machineAPIVersion)
+		})
+
+		//the node of an unhealthy machine doesn't have the self-node-remediation pod in the following contexts,
+		//so the remediation stops right after the node was found, which is enough for verifying the machine support
+		Context("OpenShift Machine API", func() {
+			BeforeEach(func() {
+				machineAPIVersion = "machine.openshift.io/v1beta1"
+			})
+
+			It("should find the machine's node", func() {
+				verifyMachineNodeWasFound()
+			})
+		})
+
+		Context("Cluster API", func() {
+			BeforeEach(func() {
+				machineAPIVersion = "cluster.x-k8s.io/v1beta1"
+			})
+
+			It("should find the machine's node", func() {
+				verifyMachineNodeWasFound()
+			})
+		})
+
+		Context("MachineDeletion strategy", func() {
+			BeforeEach(func() {
+				machineAPIVersion = "cluster.x-k8s.io/v1beta1"
+				remediationStrategy = selfnoderemediationv1alpha1.MachineDeletionRemediationStrategy
+				createSelfNodeRemediationPod()
+				updateIsRebootCapable("true")
+			})
+
+			It("Remediation flow", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyNoWatchdogFood()
+
+				verifySelfNodeRemediationPodDoesntExist()
+
+				verifyMachineDeleted(machineAPIVersion)
+
+				deleteSNR(machineSNR)
+				isSNRNeedsDeletion = false
+
+				verifyMachineSNRDoesNotExists()
+			})
+		})
+	})
+
+	Context("Unhealthy node with self-node-remediation pod but unable to reboot", func() {
+		//if the unhealthy node doesn't have watchdog and it's is-reboot-capable annotation is not true
+		//we don't want to delete the node, since it will never
+		//be in a safe state (i.e. rebooted)
+
+		Context("simulate daemonset pods assigned to nodes", func() {
+			//since we don't have a scheduler in test, we need to do its work and create pp pod for that node
+
+			BeforeEach(func() {
+				createSelfNodeRemediationPod()
+				createSNR(selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy)
+			})
+
+			AfterEach(func() {
+				deleteSelfNodeRemediationPod()
+				deleteSNR(snr)
+			})
+
+			Context("node doesn't have is-reboot-capable annotation", func() {
+				BeforeEach(func() {
+					//remove the annotation, if exists
+					deleteIsRebootCapableAnnotation()
+				})
+
+				It("snr should not have finalizers when is-reboot-capable annotation doesn't exist", func() {
+					testNoFinalizer()
+				})
+
+				It("snr should be disabled when is-reboot-capable annotation doesn't exist", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionTrue, selfnoderemediationv1alpha1.NodeNotRebootCapableReason)
+				})
+			})
+
+			Context("node's is-reboot-capable annotation is false", func() {
+				BeforeEach(func() {
+					rebootCapableAnnotationValue := "false"
+					updateIsRebootCapable(rebootCapableAnnotationValue)
+				})
+
+				It("snr should not have finalizers when is-reboot-capable annotation is false", func() {
+					testNoFinalizer()
+				})
+			})
+		})
 	})
 
-	Context("Unhealthy node with self-node-remediation pod but unable to reboot", func() {
-		//if the unhealthy node doesn't have watchdog and it's is-reboot-capable annotation is not true
-		//we don't want to delete the node, since it will never
-		//be in a safe state (i.e. rebooted)
+	Context("Unhealthy node with api-server access", func() {
+		var remediationStrategy selfnoderemediationv1alpha1.RemediationStrategyType
+		var podDeletionRules *selfnoderemediationv1alpha1.PodDeletionRules
+		var isDryRun bool
+		var approvalPolicy *selfnoderemediationv1alpha1.ApprovalPolicy
+		var escalationSteps []selfnoderemediationv1alpha1.EscalationStep
+		var powerAction selfnoderemediationv1alpha1.PowerActionType
+		var nodeRestoreRules *selfnoderemediationv1alpha1.NodeRestoreRules
+		var remediationTimeout *metav1.Duration
+		var isSNRNeedsDeletion = true
+		JustBeforeEach(func() {
+			createSelfNodeRemediationPod()
+			updateIsRebootCapable("true")
+			createSNRWithSpec(selfnoderemediationv1alpha1.SelfNodeRemediationSpec{
+				RemediationStrategy: remediationStrategy,
+				PodDeletionRules:    podDeletionRules,
+				DryRun:              isDryRun,
+				ApprovalPolicy:      approvalPolicy,
+				EscalationSteps:     escalationSteps,
+				PowerAction:         powerAction,
+				NodeRestoreRules:    nodeRestoreRules,
+				RemediationTimeout:  remediationTimeout,
+			})
+
+			By("make sure self node remediation exists with correct label")
+			verifySelfNodeRemediationPodExist()
+		})
+
+		AfterEach(func() {
+			if isSNRNeedsDeletion {
+				deleteSNR(snr)
+			}
+			isSNRNeedsDeletion = true
+			podDeletionRules = nil
+			isDryRun = false
+			approvalPolicy = nil
+			escalationSteps = nil
+			powerAction = ""
+			nodeRestoreRules = nil
+			remediationTimeout = nil
+		})
+
+		Context("ResourceDeletion strategy", func() {
+			var vaName = "some-va"
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				createVolumeAttachment(vaName)
+			})
+
+			AfterEach(func() {
+				//no need to delete pp pod or va as it was already deleted by the controller
+			})
+
+			It("Remediation flow", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				verifyNodeBackup()
+
+				verifyNoWatchdogFood()
+
+				verifySelfNodeRemediationPodDoesntExist()
+
+				verifyVaDeleted(vaName)
+
+				verifyFinalizerExists()
+
+				verifyNoExecuteTaintExist()
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+
+			})
+		})
+
+		Context("ResourceDeletion strategy with pod deletion rules", func() {
+			const (
+				excludedPodName  = "excluded-pod"
+				gracefulPodName  = "graceful-pod"
+				daemonSetPodName = "daemonset-pod"
+				staticPodName    = "static-pod"
+				excludedPodLabel = "foo.medik8s.io/excluded"
+				gracefulPodLabel = "foo.medik8s.io/graceful"
+				podLabelValue    = "true"
+			)
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				podDeletionRules = &selfnoderemediationv1alpha1.PodDeletionRules{
+					ExcludedPods:      &metav1.LabelSelector{MatchLabels: map[string]string{excludedPodLabel: podLabelValue}},
+					GracefulPods:      &metav1.LabelSelector{MatchLabels: map[string]string{gracefulPodLabel: podLabelValue}},
+					SkipDaemonSetPods: true,
+					SkipStaticPods:    true,
+				}
+
+				createPodOnUnhealthyNode(excludedPodName, func(pod *v1.Pod) {
+					pod.Labels = map[string]string{excludedPodLabel: podLabelValue}
+				})
+				createPodOnUnhealthyNode(gracefulPodName, func(pod *v1.Pod) {
+					pod.Labels = map[string]string{gracefulPodLabel: podLabelValue}
+				})
+				createPodOnUnhealthyNode(daemonSetPodName, func(pod *v1.Pod) {
+					isController := true
+					pod.OwnerReferences = []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "DaemonSet",
+						Name:       "some-ds",
+						UID:        "some-uid",
+						Controller: &isController,
+					}}
+				})
+				createPodOnUnhealthyNode(staticPodName, func(pod *v1.Pod) {
+					pod.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "mirror"}
+				})
+			})
+
+			AfterEach(func() {
+				for _, name := range []string{excludedPodName, gracefulPodName, daemonSetPodName, staticPodName} {
+					forceDeletePod(name)
+				}
+			})
+
+			It("Remediation flow", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				verifyNoWatchdogFood()
+
+				verifySelfNodeRemediationPodDoesntExist()
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+				By("Verify that only the graceful pod is terminating, and the skipped pods weren't deleted")
+				// there is no kubelet which completes the graceful deletion, so the pod stays terminating
+				Expect(getPod(gracefulPodName).DeletionTimestamp).ToNot(BeNil())
+				for _, name := range []string{excludedPodName, daemonSetPodName, staticPodName} {
+					Expect(getPod(name).DeletionTimestamp).To(BeNil())
+				}
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
+		})
+
+		Context("dry run", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				isDryRun = true
+			})
+
+			It("should record the actions without taking them", func() {
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.DryRunCompletedReason)
+
+				By("Verify that the actions were recorded in the status")
+				snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+				Expect(k8sClient.Client.Get(context.Background(), client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}, snr)).To(Succeed())
+				Expect(snr.Status.DryRunActions).To(ContainElements(
+					fmt.Sprintf("Mark node %s as unschedulable", unhealthyNodeName),
+					fmt.Sprintf("Force delete pod %s/self-node-remediation", namespace),
+				))
+
+				testNoFinalizer()
+
+				By("Verify that the node and its pods weren't changed")
+				node := &v1.Node{}
+				Expect(k8sClient.Client.Get(context.Background(), unhealthyNodeNamespacedName, node)).To(Succeed())
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+				Expect(isNoExecuteTaintExist()).To(BeFalse())
+				verifySelfNodeRemediationPodExist()
+
+				deleteSelfNodeRemediationPod()
+			})
+		})
+
+		Context("dry run is enabled after the remediation started", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			It("should complete and clean up the remediation", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+					snr.Spec.DryRun = true
+				})
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
+		})
+
+		Context("approval required", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			Context("remediation is approved", func() {
+				BeforeEach(func() {
+					approvalPolicy = &selfnoderemediationv1alpha1.ApprovalPolicy{}
+				})
+
+				It("Remediation flow", func() {
+					node := verifyNodeIsUnschedulable()
+
+					addUnschedulableTaint(node)
+
+					verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.AwaitingApprovalReason)
+
+					verifyNoTimeAssumedRebooted()
+
+					updateApprovalAnnotation(utils.RemediationApproved)
+
+					verifyTimeHasBeenRebootedExists()
+
+					verifyNoWatchdogFood()
+
+					verifySelfNodeRemediationPodDoesntExist()
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+					deleteSNR(snr)
+					isSNRNeedsDeletion = false
+
+					verifyNodeIsSchedulable()
+
+					removeUnschedulableTaint()
+
+					verifyNoExecuteTaintRemoved()
+
+					verifySNRDoesNotExists()
+				})
+			})
+
+			Context("remediation is rejected", func() {
+				BeforeEach(func() {
+					approvalPolicy = &selfnoderemediationv1alpha1.ApprovalPolicy{}
+				})
+
+				It("snr should be marked as failed", func() {
+					node := verifyNodeIsUnschedulable()
+
+					addUnschedulableTaint(node)
+
+					verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.AwaitingApprovalReason)
+
+					updateApprovalAnnotation(utils.RemediationRejected)
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionFalse, selfnoderemediationv1alpha1.ApprovalRejectedReason)
+
+					verifyNoTimeAssumedRebooted()
+
+					deleteSNR(snr)
+					isSNRNeedsDeletion = false
+
+					verifyNodeIsSchedulable()
+
+					removeUnschedulableTaint()
+
+					verifySNRDoesNotExists()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("approval times out", func() {
+				BeforeEach(func() {
+					approvalPolicy = &selfnoderemediationv1alpha1.ApprovalPolicy{
+						Timeout:       &metav1.Duration{Duration: 2 * time.Second},
+						TimeoutAction: selfnoderemediationv1alpha1.RejectApprovalTimeoutAction,
+					}
+				})
+
+				It("snr should be marked as failed", func() {
+					node := verifyNodeIsUnschedulable()
+
+					addUnschedulableTaint(node)
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionFalse, selfnoderemediationv1alpha1.ApprovalRejectedReason)
+
+					verifyNoTimeAssumedRebooted()
+
+					deleteSNR(snr)
+					isSNRNeedsDeletion = false
+
+					verifyNodeIsSchedulable()
+
+					removeUnschedulableTaint()
+
+					verifySNRDoesNotExists()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("NodeDeletion strategy", func() {
+			const ovnAnnotation = "k8s.ovn.org/node-subnets"
+			const restoredAnnotation = "foo.medik8s.io/bar"
+			const deniedAnnotation = "foo.medik8s.io/denied"
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.NodeDeletionRemediationStrategy
+				updateNodeFunc := func(node *v1.Node) {
+					if node.Annotations == nil {
+						node.Annotations = map[string]string{}
+					}
+					node.Annotations[ovnAnnotation] = "foo"
+					node.Annotations[restoredAnnotation] = "bar"
+					node.Annotations[deniedAnnotation] = "baz"
+				}
+				eventuallyUpdateNode(updateNodeFunc, false)
+			})
+
+			AfterEach(func() {
+				updateNodeFunc := func(node *v1.Node) {
+					delete(node.Annotations, ovnAnnotation)
+					delete(node.Annotations, restoredAnnotation)
+					delete(node.Annotations, deniedAnnotation)
+				}
+				eventuallyUpdateNode(updateNodeFunc, false)
+			})
+
+			It("Remediation flow", func() {
+				node := verifyNodeIsUnschedulable()
+				originalUID := node.UID
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				verifyNoWatchdogFood()
+
+				restoredNode := verifyNodeIsRestored(originalUID)
+				Expect(restoredNode.Annotations).To(HaveKey(restoredAnnotation))
+				Expect(restoredNode.Annotations).To(HaveKey(deniedAnnotation))
+				Expect(restoredNode.Annotations).ToNot(HaveKey(ovnAnnotation))
+
+				verifyFinalizerExists()
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeRestoredReason)
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+
+				deleteSelfNodeRemediationPod()
+			})
+
+			Context("with a user denylist of annotations", func() {
+				BeforeEach(func() {
+					nodeRestoreRules = &selfnoderemediationv1alpha1.NodeRestoreRules{
+						Annotations: &selfnoderemediationv1alpha1.RestoreFilter{
+							Denylist: []string{deniedAnnotation},
+						},
+					}
+				})
+
+				It("should not restore the denied annotations and the default denied annotations", func() {
+					node := verifyNodeIsUnschedulable()
+					originalUID := node.UID
+
+					addUnschedulableTaint(node)
+
+					verifyTimeHasBeenRebootedExists()
+
+					restoredNode := verifyNodeIsRestored(originalUID)
+					Expect(restoredNode.Annotations).To(HaveKey(restoredAnnotation))
+					Expect(restoredNode.Annotations).ToNot(HaveKey(deniedAnnotation))
+					Expect(restoredNode.Annotations).ToNot(HaveKey(ovnAnnotation))
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeRestoredReason)
+
+					deleteSNR(snr)
+					isSNRNeedsDeletion = false
+
+					verifyNoExecuteTaintRemoved()
+
+					verifySNRDoesNotExists()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("remediation timeout", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				remediationTimeout = &metav1.Duration{Duration: time.Second}
+			})
+
+			It("snr should be marked as failed and the node should be schedulable again", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				By("Verify that the remediation timed out")
+				Eventually(func() (string, error) {
+					snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+					if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}, snr); err != nil {
+						return "", err
+					}
+					condition := meta.FindStatusCondition(snr.Status.Conditions, selfnoderemediationv1alpha1.SucceededConditionType)
+					if condition == nil {
+						return "", nil
+					}
+					return condition.Reason, nil
+				}, 30*time.Second, 250*time.Millisecond).Should(Equal(selfnoderemediationv1alpha1.RemediationTimedOutReason))
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifySNRDoesNotExists()
+
+				deleteSelfNodeRemediationPod()
+			})
+		})
+
+		Context("node was remediated too many times", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				recentRemediation := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+				updateNodeFunc := func(node *v1.Node) {
+					if node.Annotations == nil {
+						node.Annotations = map[string]string{}
+					}
+					history := []string{}
+					for i := 0; i < maxRemediationsPerNode; i++ {
+						history = append(history, recentRemediation)
+					}
+					node.Annotations[utils.RemediationHistoryAnnotation] = strings.Join(history, ",")
+				}
+				eventuallyUpdateNode(updateNodeFunc, false)
+			})
+
+			It("snr should be marked as failed", func() {
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionFalse, selfnoderemediationv1alpha1.TooManyRemediationsReason)
+
+				testNoFinalizer()
+
+				deleteSelfNodeRemediationPod()
+			})
+		})
+
+		Context("too many nodes are being remediated", func() {
+			var otherSNRs []*selfnoderemediationv1alpha1.SelfNodeRemediation
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				otherSNRs = nil
+			})
+
+			AfterEach(func() {
+				deleteOtherSNRs(otherSNRs)
+			})
+
+			Context("max concurrent remediations reached", func() {
+				BeforeEach(func() {
+					// active remediations of other nodes, which already have the finalizer
+					for i := 0; i < maxConcurrentRemediations; i++ {
+						otherSNRs = append(otherSNRs, createOtherSNR(fmt.Sprintf("active-node-%d", i), true))
+					}
+				})
+
+				It("snr should be queued", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationQueuedReason)
+
+					testNoFinalizer()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("remediations of several nodes are created at once", func() {
+				BeforeEach(func() {
+					// pending remediations of other nodes, which were created before the snr of the unhealthy node, or
+					// at the same time with a name which is ordered before it
+					for i := 0; i < maxConcurrentRemediations; i++ {
+						otherSNRs = append(otherSNRs, createOtherSNR(fmt.Sprintf("another-node-%d", i), false))
+					}
+				})
+
+				It("snr should be queued until the preceding remediations are done", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationQueuedReason)
+
+					testNoFinalizer()
+
+					deleteOtherSNRs(otherSNRs)
+					otherSNRs = nil
+
+					By("Verify that the remediation starts once the preceding remediations are done")
+					Eventually(func() (bool, error) {
+						startedSNR := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+						if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(snr), startedSNR); err != nil {
+							return false, err
+						}
+						return controllerutil.ContainsFinalizer(startedSNR, controllers.SNRFinalizer), nil
+					}, 15*time.Second, 250*time.Millisecond).Should(BeTrue())
+
+					deleteStartedSNR(snr)
+					isSNRNeedsDeletion = false
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("finished remediations exist", func() {
+				BeforeEach(func() {
+					// finished remediations of other nodes, which would reach the storm threshold together with the
+					// snr of the unhealthy node if they were counted
+					for i := 0; i < remediationStormThreshold-1; i++ {
+						otherSNRs = append(otherSNRs, createFinishedSNR(fmt.Sprintf("finished-node-%d", i)))
+					}
+				})
+
+				It("snr should start", func() {
+					verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeTaintedReason)
+
+					deleteStartedSNR(snr)
+					isSNRNeedsDeletion = false
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("remediation storm", func() {
+				BeforeEach(func() {
+					// together with the snr of the unhealthy node, the storm threshold is reached
+					for i := 0; i < remediationStormThreshold-1; i++ {
+						otherSNRs = append(otherSNRs, createOtherSNR(fmt.Sprintf("unhealthy-node-%d", i), false))
+					}
+				})
+
+				It("snr should be paused", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationStormReason)
+
+					testNoFinalizer()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("remediation schedule holds remediations", func() {
+			var remediationSchedule *selfnoderemediationv1alpha1.RemediationSchedule
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			JustBeforeEach(func() {
+				config := &selfnoderemediationv1alpha1.SelfNodeRemediationConfig{}
+				config.Name = selfnoderemediationv1alpha1.ConfigCRName
+				config.Namespace = scheduleConfigNamespace
+				config.Spec.RemediationSchedule = remediationSchedule
+				Expect(k8sClient.Create(context.Background(), config)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				config := &selfnoderemediationv1alpha1.SelfNodeRemediationConfig{}
+				config.Name = selfnoderemediationv1alpha1.ConfigCRName
+				config.Namespace = scheduleConfigNamespace
+				Expect(k8sClient.Delete(context.Background(), config)).To(Succeed())
+			})
+
+			Context("remediations are paused", func() {
+				BeforeEach(func() {
+					remediationSchedule = &selfnoderemediationv1alpha1.RemediationSchedule{Paused: true}
+				})
+
+				It("snr should be held", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationsPausedReason)
+
+					testNoFinalizer()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("blackout window is active", func() {
+				BeforeEach(func() {
+					remediationSchedule = &selfnoderemediationv1alpha1.RemediationSchedule{
+						BlackoutWindows: []selfnoderemediationv1alpha1.ScheduleWindow{{
+							Name:     "always",
+							Schedule: "* * * * *",
+							Duration: metav1.Duration{Duration: time.Hour},
+						}},
+					}
+				})
+
+				It("snr should be held", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.BlackoutWindowReason)
+
+					testNoFinalizer()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("node is under planned maintenance", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			Context("machine config update is in progress", func() {
+				BeforeEach(func() {
+					eventuallyUpdateNode(func(node *v1.Node) {
+						node.Annotations = map[string]string{utils.MachineConfigStateAnnotation: utils.MachineConfigStateWorking}
+					}, false)
+				})
+
+				It("snr should be deferred until the update is done", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.MachineConfigUpdateInProgressReason)
+
+					testNoFinalizer()
+
+					eventuallyUpdateNode(func(node *v1.Node) {
+						node.Annotations[utils.MachineConfigStateAnnotation] = "Done"
+					}, false)
+
+					verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeTaintedReason)
+
+					deleteStartedSNR(snr)
+					isSNRNeedsDeletion = false
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+
+			Context("NodeMaintenance exists", func() {
+				BeforeEach(func() {
+					Expect(k8sClient.Create(context.Background(), newNodeMaintenance())).To(Succeed())
+				})
+
+				AfterEach(func() {
+					Expect(k8sClient.Delete(context.Background(), newNodeMaintenance())).To(Succeed())
+				})
+
+				It("snr should be deferred", func() {
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeUnderMaintenanceReason)
+
+					testNoFinalizer()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("other control-plane nodes are unavailable", func() {
+			otherControlPlaneNodes := []string{"control-plane-2", "control-plane-3"}
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				eventuallyUpdateNode(func(node *v1.Node) {
+					node.Labels[utils.ControlPlaneLabelName] = ""
+				}, false)
+				for _, name := range otherControlPlaneNodes {
+					node := &v1.Node{}
+					node.Name = name
+					node.Labels = map[string]string{utils.ControlPlaneLabelName: ""}
+					Expect(k8sClient.Create(context.Background(), node)).To(Succeed())
+				}
+				// only one of the other control-plane nodes is ready, so the quorum of 2 breaks while the node reboots
+				updateNodeReady(otherControlPlaneNodes[0])
+			})
+
+			AfterEach(func() {
+				for _, name := range otherControlPlaneNodes {
+					node := &v1.Node{}
+					node.Name = name
+					Expect(k8sClient.Delete(context.Background(), node)).To(Succeed())
+				}
+			})
+
+			It("snr should be held until the quorum is safe", func() {
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.EtcdQuorumGuardReason)
+
+				testNoFinalizer()
+
+				updateNodeReady(otherControlPlaneNodes[1])
+
+				verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeTaintedReason)
+
+				deleteStartedSNR(snr)
+				isSNRNeedsDeletion = false
+
+				deleteSelfNodeRemediationPod()
+			})
+		})
+
+		Context("self-initiated remediation", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			AfterEach(func() {
+				setNodeConditions(unhealthyNodeName, nil)
+			})
+
+			It("snr should be deleted once the node is ready", func() {
+				eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+					snr.Annotations = map[string]string{utils.SelfInitiatedRemediationAnnotation: "self diagnostics failed"}
+				})
+
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+				By("Verify that the snr isn't deleted while the node isn't ready")
+				Consistently(func() error {
+					return k8sClient.Get(context.Background(), client.ObjectKeyFromObject(snr), &selfnoderemediationv1alpha1.SelfNodeRemediation{})
+				}, 2*time.Second, 250*time.Millisecond).Should(Succeed())
+
+				updateNodeReady(unhealthyNodeName)
+				// trigger a reconcile without waiting for the requeue
+				eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+					snr.Labels = map[string]string{"test": "ready"}
+				})
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
+		})
+
+		Context("escalation steps", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+			})
+
+			Context("node doesn't become ready", func() {
+				BeforeEach(func() {
+					escalationSteps = []selfnoderemediationv1alpha1.EscalationStep{{
+						Action:  selfnoderemediationv1alpha1.RestartKubeletEscalationAction,
+						Timeout: &metav1.Duration{Duration: time.Second},
+					}}
+				})
+
+				It("node should be fenced after the last step", func() {
+					node := verifyNodeIsUnschedulable()
+
+					By("Verify that the escalation step was executed")
+					snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+					Expect(k8sClient.Client.Get(context.Background(), client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}, snr)).To(Succeed())
+					Expect(snr.Status.ExecutedEscalationStep).ToNot(BeNil())
+					Expect(*snr.Status.ExecutedEscalationStep).To(Equal(0))
+					Expect(snr.Status.EscalationStep).ToNot(BeNil())
+					Expect(*snr.Status.EscalationStep).To(Equal(1))
+
+					addUnschedulableTaint(node)
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+					deleteSNR(snr)
+					isSNRNeedsDeletion = false
+
+					verifyNodeIsSchedulable()
+
+					removeUnschedulableTaint()
+
+					verifyNoExecuteTaintRemoved()
+
+					verifySNRDoesNotExists()
+				})
+			})
+
+			Context("node becomes ready", func() {
+				BeforeEach(func() {
+					escalationSteps = []selfnoderemediationv1alpha1.EscalationStep{{
+						Action:  selfnoderemediationv1alpha1.RestartKubeletEscalationAction,
+						Timeout: &metav1.Duration{Duration: time.Minute},
+					}}
+				})
+
+				AfterEach(func() {
+					setNodeConditions(unhealthyNodeName, nil)
+				})
+
+				It("remediation should complete without fencing the node", func() {
+					verifyConditions(metav1.ConditionTrue, metav1.ConditionUnknown, metav1.ConditionFalse, selfnoderemediationv1alpha1.EscalatingReason)
+
+					setNodeConditions(unhealthyNodeName, []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.Now()}})
+					// trigger a reconcile without waiting for the requeue
+					eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+						snr.Labels = map[string]string{"test": "ready"}
+					})
+
+					verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.RecoveredByEscalationReason)
+
+					testNoFinalizer()
+
+					By("Verify that the node wasn't fenced")
+					node := &v1.Node{}
+					Expect(k8sClient.Client.Get(context.Background(), unhealthyNodeNamespacedName, node)).To(Succeed())
+					Expect(node.Spec.Unschedulable).To(BeFalse())
+					Expect(isNoExecuteTaintExist()).To(BeFalse())
+					verifySelfNodeRemediationPodExist()
+
+					deleteSelfNodeRemediationPod()
+				})
+			})
+		})
+
+		Context("node is kept down", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				powerAction = selfnoderemediationv1alpha1.PowerOffPowerAction
+			})
+
+			It("fencing should complete after the safe time", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				verifyNoWatchdogFood()
+
+				verifySelfNodeRemediationPodDoesntExist()
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.NodeKeptDownReason)
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
+		})
+
+		Context("node reboot is confirmed by its boot ID", func() {
+			var hostBootID string
+
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				// the agent of the unhealthy node reports the boot ID of this host, so its peer gets the same answer
+				var err error
+				hostBootID, err = utils.GetLinuxBootID()
+				Expect(err).ToNot(HaveOccurred())
+				nodeProber.setBootID(hostBootID)
+				setNodeBootID(unhealthyNodeName, hostBootID)
+				updateNodeReady(unhealthyNodeName)
+			})
+
+			AfterEach(func() {
+				setNodeBootID(unhealthyNodeName, "")
+				setNodeConditions(unhealthyNodeName, nil)
+				nodeProber.setBootID("")
+			})
+
+			It("fencing should complete before the safe time", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				By("Verify that the boot ID has been added to SNR status")
+				snrKey := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+				Eventually(func() (string, error) {
+					err := k8sClient.Client.Get(context.Background(), snrKey, snr)
+					return snr.Status.NodeBootID, err
+				}, 5*time.Second, 250*time.Millisecond).Should(Equal(hostBootID))
+
+				// the node is up again after its reboot
+				setNodeBootID(unhealthyNodeName, "boot-2")
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+				Expect(k8sClient.Client.Get(context.Background(), snrKey, snr)).To(Succeed())
+				Expect(time.Now()).To(BeTemporally("<", snr.Status.TimeAssumedRebooted.Time))
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
 
-		Context("simulate daemonset pods assigned to nodes", func() {
-			//since we don't have a scheduler in test, we need to do its work and create pp pod for that node
+				verifySNRDoesNotExists()
+			})
+		})
 
+		Context("node still answers with the same boot ID", func() {
 			BeforeEach(func() {
-				createSelfNodeRemediationPod()
-				createSNR(selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy)
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				hostBootID, err := utils.GetLinuxBootID()
+				Expect(err).ToNot(HaveOccurred())
+				nodeProber.setBootID(hostBootID)
 			})
 
 			AfterEach(func() {
-				deleteSelfNodeRemediationPod()
-				deleteSNR(snr)
+				nodeProber.setBootID("")
 			})
 
-			Context("node doesn't have is-reboot-capable annotation", func() {
-				BeforeEach(func() {
-					//remove the annotation, if exists
-					deleteIsRebootCapableAnnotation()
-				})
+			It("fencing should be postponed until the node was rebooted", func() {
+				node := verifyNodeIsUnschedulable()
 
-				It("snr should not have finalizers when is-reboot-capable annotation doesn't exist", func() {
-					testNoFinalizer()
-				})
-			})
+				addUnschedulableTaint(node)
 
-			Context("node's is-reboot-capable annotation is false", func() {
-				BeforeEach(func() {
-					rebootCapableAnnotationValue := "false"
-					updateIsRebootCapable(rebootCapableAnnotationValue)
-				})
+				verifyTimeHasBeenRebootedExists()
 
-				It("snr should not have finalizers when is-reboot-capable annotation is false", func() {
-					testNoFinalizer()
-				})
+				verifyFencingPostponed(15 * time.Second)
+
+				// the node answers after its reboot, while its status wasn't updated yet
+				nodeProber.setBootID("boot-2")
+
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
+
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
 			})
 		})
-	})
 
-	Context("Unhealthy node with api-server access", func() {
-		var remediationStrategy selfnoderemediationv1alpha1.RemediationStrategyType
-		var isSNRNeedsDeletion = true
-		JustBeforeEach(func() {
-			createSelfNodeRemediationPod()
-			updateIsRebootCapable("true")
-			createSNR(remediationStrategy)
+		Context("node reboot is never confirmed", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+				hostBootID, err := utils.GetLinuxBootID()
+				Expect(err).ToNot(HaveOccurred())
+				nodeProber.setBootID(hostBootID)
+			})
 
-			By("make sure self node remediation exists with correct label")
-			verifySelfNodeRemediationPodExist()
-		})
+			AfterEach(func() {
+				nodeProber.setBootID("")
+			})
+
+			It("snr should be marked as failed once fencing was postponed for too long", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				By("Verify that fencing is postponed")
+				Eventually(getSucceededConditionReason, 15*time.Second, 250*time.Millisecond).Should(Equal(selfnoderemediationv1alpha1.FencingPostponedReason))
+
+				By("Verify that the remediation failed")
+				Eventually(getSucceededConditionReason, maxFencingPostponement+10*time.Second, 250*time.Millisecond).Should(Equal(selfnoderemediationv1alpha1.RebootNotConfirmedReason))
+				snrKey := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+				Expect(k8sClient.Client.Get(context.Background(), snrKey, snr)).To(Succeed())
+				Expect(snr.Status.Phase).ToNot(BeNil())
+				Expect(*snr.Status.Phase).To(Equal("Failed"))
+
+				By("Verify that the node wasn't fenced and is still unschedulable")
+				Consistently(func() (bool, error) {
+					node := &v1.Node{}
+					err := k8sClient.Client.Get(context.Background(), unhealthyNodeNamespacedName, node)
+					return node.Spec.Unschedulable, err
+				}, 5*time.Second, 250*time.Millisecond).Should(BeTrue())
 
-		AfterEach(func() {
-			if isSNRNeedsDeletion {
 				deleteSNR(snr)
-			}
-			isSNRNeedsDeletion = true
+				isSNRNeedsDeletion = false
+
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
 		})
 
-		Context("ResourceDeletion strategy", func() {
-			var vaName = "some-va"
+		Context("node status has a stale boot ID", func() {
+			var hostBootID string
 
 			BeforeEach(func() {
 				remediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
-				createVolumeAttachment(vaName)
+				var err error
+				hostBootID, err = utils.GetLinuxBootID()
+				Expect(err).ToNot(HaveOccurred())
+				nodeProber.setBootID(hostBootID)
+				// the node was rebooted earlier, while its kubelet didn't update the status
+				setNodeBootID(unhealthyNodeName, "stale-boot")
 			})
 
 			AfterEach(func() {
-				//no need to delete pp pod or va as it was already deleted by the controller
+				setNodeBootID(unhealthyNodeName, "")
+				nodeProber.setBootID("")
 			})
 
-			It("Remediation flow", func() {
+			It("should record the boot ID of the host and not fence the node before it was rebooted", func() {
 				node := verifyNodeIsUnschedulable()
 
 				addUnschedulableTaint(node)
 
 				verifyTimeHasBeenRebootedExists()
 
-				verifyNodeBackup()
+				By("Verify that the boot ID of the host has been added to SNR status")
+				snrKey := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+				Eventually(func() (string, error) {
+					err := k8sClient.Client.Get(context.Background(), snrKey, snr)
+					return snr.Status.NodeBootID, err
+				}, 5*time.Second, 250*time.Millisecond).Should(Equal(hostBootID))
 
-				verifyNoWatchdogFood()
+				verifyFencingPostponed(15 * time.Second)
 
-				verifySelfNodeRemediationPodDoesntExist()
+				// the node doesn't answer anymore after the reboot started
+				nodeProber.setBootID("")
 
-				verifyVaDeleted(vaName)
+				verifyConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, selfnoderemediationv1alpha1.FencingCompletedReason)
 
-				verifyFinalizerExists()
+				deleteSNR(snr)
+				isSNRNeedsDeletion = false
 
-				verifyNoExecuteTaintExist()
+				verifyNodeIsSchedulable()
+
+				removeUnschedulableTaint()
+
+				verifyNoExecuteTaintRemoved()
+
+				verifySNRDoesNotExists()
+			})
+		})
+
+		Context("OutOfServiceTaint strategy", func() {
+			BeforeEach(func() {
+				remediationStrategy = selfnoderemediationv1alpha1.OutOfServiceTaintRemediationStrategy
+			})
+
+			It("Remediation flow", func() {
+				node := verifyNodeIsUnschedulable()
+
+				addUnschedulableTaint(node)
+
+				verifyTimeHasBeenRebootedExists()
+
+				verifyNoWatchdogFood()
+
+				verifyOutOfServiceTaintExist()
+
+				verifyFinalizerExists()
 
 				deleteSNR(snr)
 				isSNRNeedsDeletion = false
@@ -182,10 +1338,11 @@ var _ = Describe("snr Controller", func() {
 
 				removeUnschedulableTaint()
 
-				verifyNoExecuteTaintRemoved()
+				verifyOutOfServiceTaintRemoved()
 
 				verifySNRDoesNotExists()
 
+				deleteSelfNodeRemediationPod()
 			})
 		})
 	})
@@ -304,6 +1461,38 @@ func verifyNoExecuteTaintExist() {
 	Eventually(isNoExecuteTaintExist, 10*time.Second, 200*time.Millisecond).Should(BeTrue())
 }
 
+func verifyNodeIsRestored(originalUID types.UID) *v1.Node {
+	By("Verify that node was deleted and restored")
+	node := &v1.Node{}
+	EventuallyWithOffset(1, func() (types.UID, error) {
+		err := k8sClient.Reader.Get(context.TODO(), unhealthyNodeNamespacedName, node)
+		if apierrors.IsNotFound(err) {
+			return originalUID, nil
+		}
+		return node.UID, err
+	}, 60*time.Second, 250*time.Millisecond).ShouldNot(Equal(originalUID))
+	return node
+}
+
+func verifyOutOfServiceTaintRemoved() {
+	By("Verify that node does not have out-of-service taint")
+	Eventually(isOutOfServiceTaintExist, 10*time.Second, 200*time.Millisecond).Should(BeFalse())
+}
+
+func verifyOutOfServiceTaintExist() {
+	By("Verify that node has out-of-service taint")
+	Eventually(isOutOfServiceTaintExist, 30*time.Second, 200*time.Millisecond).Should(BeTrue())
+}
+
+func isOutOfServiceTaintExist() (bool, error) {
+	node := &v1.Node{}
+	err := k8sClient.Reader.Get(context.TODO(), unhealthyNodeNamespacedName, node)
+	if err != nil {
+		return false, err
+	}
+	return utils.TaintExists(node.Spec.Taints, controllers.OutOfServiceTaint), nil
+}
+
 func isNoExecuteTaintExist() (bool, error) {
 	node := &v1.Node{}
 	err := k8sClient.Reader.Get(context.TODO(), unhealthyNodeNamespacedName, node)
@@ -337,6 +1526,32 @@ func verifyNodeBackup() {
 	ExpectWithOffset(1, nodeToRestore).To(Equal(node))
 }
 
+func verifyConditions(processing, succeeded, disabled metav1.ConditionStatus, reason string) {
+	By("Verify that SNR conditions were updated")
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	snrNamespacedName := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+	EventuallyWithOffset(1, func() error {
+		if err := k8sClient.Client.Get(context.Background(), snrNamespacedName, snr); err != nil {
+			return err
+		}
+		expected := map[string]metav1.ConditionStatus{
+			selfnoderemediationv1alpha1.ProcessingConditionType: processing,
+			selfnoderemediationv1alpha1.SucceededConditionType:  succeeded,
+			selfnoderemediationv1alpha1.DisabledConditionType:   disabled,
+		}
+		for conditionType, status := range expected {
+			condition := meta.FindStatusCondition(snr.Status.Conditions, conditionType)
+			if condition == nil {
+				return fmt.Errorf("condition %s doesn't exist", conditionType)
+			}
+			if condition.Status != status || condition.Reason != reason {
+				return fmt.Errorf("condition %s is %s with reason %s", conditionType, condition.Status, condition.Reason)
+			}
+		}
+		return nil
+	}, 5*time.Second, 250*time.Millisecond).Should(Succeed())
+}
+
 func verifyTimeHasBeenRebootedExists() {
 	By("Verify that time has been added to SNR status")
 	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
@@ -348,6 +1563,26 @@ func verifyTimeHasBeenRebootedExists() {
 	}, 5*time.Second, 250*time.Millisecond).ShouldNot(BeZero())
 }
 
+func verifyNoTimeAssumedRebooted() {
+	By("Verify that time assumed rebooted wasn't set")
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	snrKey := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+	ConsistentlyWithOffset(1, func() (*metav1.Time, error) {
+		err := k8sClient.Client.Get(context.Background(), snrKey, snr)
+		return snr.Status.TimeAssumedRebooted, err
+	}, 2*time.Second, 250*time.Millisecond).Should(BeNil())
+}
+
+func updateApprovalAnnotation(value string) {
+	By("Annotate the snr with the approval decision")
+	eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+		if snr.Annotations == nil {
+			snr.Annotations = map[string]string{}
+		}
+		snr.Annotations[utils.RemediationApprovalAnnotation] = value
+	})
+}
+
 func verifySNRDoesNotExists() {
 	By("Verify that SNR does not exit")
 	Eventually(func() bool {
@@ -399,12 +1634,65 @@ func deleteSNR(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
 	ExpectWithOffset(1, k8sClient.Client.Delete(context.Background(), snr)).To(Succeed(), "failed to delete snr CR")
 }
 
+// deleteStartedSNR deletes the snr of a remediation which started, without waiting for the remediation to complete
+func deleteStartedSNR(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+	deleteSNR(snr)
+	eventuallyUpdateSNR(func(snr *selfnoderemediationv1alpha1.SelfNodeRemediation) {
+		controllerutil.RemoveFinalizer(snr, controllers.SNRFinalizer)
+	})
+	verifySNRDoesNotExists()
+}
+
 func createSNR(strategy selfnoderemediationv1alpha1.RemediationStrategyType) {
+	createSNRWithSpec(selfnoderemediationv1alpha1.SelfNodeRemediationSpec{RemediationStrategy: strategy})
+}
+
+func createSNRWithSpec(spec selfnoderemediationv1alpha1.SelfNodeRemediationSpec) {
 	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
 	snr.Name = unhealthyNodeName
 	snr.Namespace = snrNamespace
-	snr.Spec.RemediationStrategy = strategy
+	snr.Spec = spec
+	ExpectWithOffset(1, k8sClient.Client.Create(context.TODO(), snr)).To(Succeed(), "failed to create snr CR")
+}
+
+// createOtherSNR creates an snr for a node which doesn't exist, so that it isn't remediated by the tested reconcilers
+func createOtherSNR(name string, withFinalizer bool) *selfnoderemediationv1alpha1.SelfNodeRemediation {
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	snr.Name = name
+	snr.Namespace = snrNamespace
+	snr.Spec.RemediationStrategy = selfnoderemediationv1alpha1.ResourceDeletionRemediationStrategy
+	if withFinalizer {
+		controllerutil.AddFinalizer(snr, controllers.SNRFinalizer)
+	}
 	ExpectWithOffset(1, k8sClient.Client.Create(context.TODO(), snr)).To(Succeed(), "failed to create snr CR")
+	return snr
+}
+
+// createFinishedSNR creates the snr of another node, whose remediation failed
+func createFinishedSNR(name string) *selfnoderemediationv1alpha1.SelfNodeRemediation {
+	snr := createOtherSNR(name, false)
+	failedPhase := "Failed"
+	snr.Status.Phase = &failedPhase
+	meta.SetStatusCondition(&snr.Status.Conditions, metav1.Condition{
+		Type:   selfnoderemediationv1alpha1.SucceededConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: selfnoderemediationv1alpha1.RemediationTimedOutReason,
+	})
+	ExpectWithOffset(1, k8sClient.Client.Status().Update(context.TODO(), snr)).To(Succeed(), "failed to update snr status")
+	return snr
+}
+
+func deleteOtherSNRs(snrs []*selfnoderemediationv1alpha1.SelfNodeRemediation) {
+	for _, snr := range snrs {
+		EventuallyWithOffset(1, func() error {
+			if err := k8sClient.Client.Get(context.Background(), client.ObjectKeyFromObject(snr), snr); err != nil {
+				return err
+			}
+			controllerutil.RemoveFinalizer(snr, controllers.SNRFinalizer)
+			return k8sClient.Client.Update(context.Background(), snr)
+		}, 5*time.Second, 250*time.Millisecond).Should(Succeed())
+		ExpectWithOffset(1, k8sClient.Client.Delete(context.Background(), snr)).To(Succeed(), "failed to delete snr CR")
+	}
 }
 
 func createSelfNodeRemediationPod() {
@@ -421,6 +1709,34 @@ func createSelfNodeRemediationPod() {
 	ExpectWithOffset(1, k8sClient.Client.Create(context.Background(), pod)).To(Succeed())
 }
 
+func createPodOnUnhealthyNode(name string, updateFunc func(pod *v1.Pod)) {
+	pod := &v1.Pod{}
+	pod.Spec.NodeName = unhealthyNodeName
+	pod.Name = name
+	pod.Namespace = namespace
+	pod.Spec.Containers = []v1.Container{{
+		Name:  "foo",
+		Image: "foo",
+	}}
+	updateFunc(pod)
+	ExpectWithOffset(1, k8sClient.Client.Create(context.Background(), pod)).To(Succeed())
+}
+
+func getPod(name string) *v1.Pod {
+	pod := &v1.Pod{}
+	ExpectWithOffset(1, k8sClient.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, pod)).To(Succeed())
+	return pod
+}
+
+func forceDeletePod(name string) {
+	pod := &v1.Pod{}
+	pod.Name = name
+	pod.Namespace = namespace
+	var grace client.GracePeriodSeconds = 0
+	err := k8sClient.Client.Delete(context.Background(), pod, grace)
+	ExpectWithOffset(1, err == nil || apierrors.IsNotFound(err)).To(BeTrue(), "failed to delete pod")
+}
+
 func deleteSelfNodeRemediationPod() {
 	pod := &v1.Pod{}
 	pod.Name = "self-node-remediation"
@@ -506,3 +1822,141 @@ func eventuallyUpdateNode(updateFunc func(*v1.Node), isStatusUpdate bool) {
 	}, 5*time.Second, 250*time.Millisecond).Should(Succeed())
 
 }
+
+func updateNodeReady(name string) {
+	setNodeConditions(name, []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}})
+}
+
+func setNodeConditions(name string, conditions []v1.NodeCondition) {
+	node := &v1.Node{}
+	ExpectWithOffset(2, k8sClient.Client.Get(context.Background(), client.ObjectKey{Name: name}, node)).To(Succeed())
+	node.Status.Conditions = conditions
+	ExpectWithOffset(2, k8sClient.Client.Status().Update(context.Background(), node)).To(Succeed())
+}
+
+// verifyFencingPostponed verifies that the fencing of the unhealthy node doesn't complete within the given duration
+func verifyFencingPostponed(duration time.Duration) {
+	By("Verify that fencing is postponed")
+	ConsistentlyWithOffset(1, getSucceededConditionReason, duration, 500*time.Millisecond).ShouldNot(Equal(selfnoderemediationv1alpha1.FencingCompletedReason))
+	reason, err := getSucceededConditionReason()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, reason).To(Equal(selfnoderemediationv1alpha1.FencingPostponedReason))
+}
+
+// getSucceededConditionReason returns the reason of the Succeeded condition of the unhealthy node's snr
+func getSucceededConditionReason() (string, error) {
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	if err := k8sClient.Client.Get(context.Background(), client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}, snr); err != nil {
+		return "", err
+	}
+	if condition := meta.FindStatusCondition(snr.Status.Conditions, selfnoderemediationv1alpha1.SucceededConditionType); condition != nil {
+		return condition.Reason, nil
+	}
+	return "", nil
+}
+
+func setNodeBootID(name string, bootID string) {
+	node := &v1.Node{}
+	ExpectWithOffset(1, k8sClient.Client.Get(context.Background(), client.ObjectKey{Name: name}, node)).To(Succeed())
+	node.Status.NodeInfo.BootID = bootID
+	ExpectWithOffset(1, k8sClient.Client.Status().Update(context.Background(), node)).To(Succeed())
+}
+
+func eventuallyUpdateSNR(updateFunc func(*selfnoderemediationv1alpha1.SelfNodeRemediation)) {
+	By("Verify that snr was updated successfully")
+
+	EventuallyWithOffset(1, func() error {
+		snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+		snrKey := client.ObjectKey{Name: unhealthyNodeName, Namespace: snrNamespace}
+		if err := k8sClient.Client.Get(context.TODO(), snrKey, snr); err != nil {
+			return err
+		}
+		updateFunc(snr)
+		return k8sClient.Client.Update(context.TODO(), snr)
+	}, 5*time.Second, 250*time.Millisecond).Should(Succeed())
+}
+
+func newNodeMaintenance() *unstructured.Unstructured {
+	nm := &unstructured.Unstructured{}
+	nm.SetAPIVersion("nodemaintenance.medik8s.io/v1beta1")
+	nm.SetKind("NodeMaintenance")
+	nm.SetName("nm-" + unhealthyNodeName)
+	nm.Object["spec"] = map[string]interface{}{"nodeName": unhealthyNodeName}
+	return nm
+}
+
+func newMachine(apiVersion string) *unstructured.Unstructured {
+	machine := &unstructured.Unstructured{}
+	machine.SetAPIVersion(apiVersion)
+	machine.SetKind("Machine")
+	machine.SetName(machineName)
+	machine.SetNamespace(snrNamespace)
+	return machine
+}
+
+func createMachine(apiVersion string) {
+	machine := newMachine(apiVersion)
+	ExpectWithOffset(1, k8sClient.Client.Create(context.Background(), machine)).To(Succeed())
+
+	// nodeRef is part of the status, so it needs to be set after the machine was created
+	ExpectWithOffset(1, unstructured.SetNestedField(machine.Object, unhealthyNodeName, "status", "nodeRef", "name")).To(Succeed())
+	ExpectWithOffset(1, k8sClient.Client.Status().Update(context.Background(), machine)).To(Succeed())
+}
+
+func deleteMachine(apiVersion string) {
+	err := k8sClient.Client.Delete(context.Background(), newMachine(apiVersion))
+	if !apierrors.IsNotFound(err) {
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	}
+}
+
+func verifyMachineDeleted(apiVersion string) {
+	By("Verify that the machine was deleted")
+	EventuallyWithOffset(1, func() bool {
+		machine := newMachine(apiVersion)
+		err := k8sClient.Client.Get(context.Background(), client.ObjectKeyFromObject(machine), machine)
+		return apierrors.IsNotFound(err)
+	}, 5*time.Second, 250*time.Millisecond).Should(BeTrue())
+}
+
+func verifyMachineSNRDoesNotExists() {
+	By("Verify that SNR of the machine does not exist")
+	EventuallyWithOffset(1, func() bool {
+		snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+		snrNamespacedName := client.ObjectKey{Name: machineName, Namespace: snrNamespace}
+		err := k8sClient.Get(context.Background(), snrNamespacedName, snr)
+		return apierrors.IsNotFound(err)
+	}, 5*time.Second, 250*time.Millisecond).Should(BeTrue())
+}
+
+func createMachineSNR(machineAPIVersion string, strategy selfnoderemediationv1alpha1.RemediationStrategyType) *selfnoderemediationv1alpha1.SelfNodeRemediation {
+	machine := newMachine(machineAPIVersion)
+	ExpectWithOffset(1, k8sClient.Client.Get(context.Background(), client.ObjectKeyFromObject(machine), machine)).To(Succeed())
+
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	snr.Name = machineName
+	snr.Namespace = snrNamespace
+	snr.Spec.RemediationStrategy = strategy
+	snr.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: machineAPIVersion,
+		Kind:       "Machine",
+		Name:       machineName,
+		UID:        machine.GetUID(),
+	}}
+	ExpectWithOffset(1, k8sClient.Client.Create(context.TODO(), snr)).To(Succeed(), "failed to create snr CR")
+	return snr
+}
+
+// verifyMachineNodeWasFound checks that the remediation of the machine's node was started,
+// by checking that it failed on the missing self-node-remediation pod of the node
+func verifyMachineNodeWasFound() {
+	By("Verify that the node of the machine was found")
+	snr := &selfnoderemediationv1alpha1.SelfNodeRemediation{}
+	snrKey := client.ObjectKey{Name: machineName, Namespace: snrNamespace}
+	EventuallyWithOffset(1, func() (string, error) {
+		err := k8sClient.Client.Get(context.Background(), snrKey, snr)
+		return snr.Status.LastError, err
+	}, 10*time.Second, 250*time.Millisecond).Should(Equal("Node is not capable to reboot itself"))
+
+	ExpectWithOffset(1, (&controllers.SelfNodeRemediationReconciler{}).WasLastSeenSnrMachine()).To(BeTrue())
+}
@@ -18,15 +18,21 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -35,6 +41,7 @@ import (
 	"github.com/medik8s/self-node-remediation/pkg/apply"
 	"github.com/medik8s/self-node-remediation/pkg/certificates"
 	"github.com/medik8s/self-node-remediation/pkg/render"
+	"github.com/medik8s/self-node-remediation/pkg/schedule"
 )
 
 // SelfNodeRemediationConfigReconciler reconciles a SelfNodeRemediationConfig object
@@ -45,8 +52,17 @@ type SelfNodeRemediationConfigReconciler struct {
 	InstallFileFolder string
 	DefaultPpcCreator func(c client.Client) error
 	Namespace         string
+	Recorder          record.EventRecorder
 }
 
+const (
+	eventReasonRemediationScheduleChanged = "RemediationScheduleChanged"
+	// defaultSelfInitiatedRemediationFailureDuration is the default of SelfInitiatedRemediation.FailureDuration
+	defaultSelfInitiatedRemediationFailureDuration = 5 * time.Minute
+	// defaultMaxFencingPostponement is the default of MaxFencingPostponement
+	defaultMaxFencingPostponement = 10 * time.Minute
+)
+
 //+kubebuilder:rbac:groups=self-node-remediation.medik8s.io,resources=selfnoderemediationconfigs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=self-node-remediation.medik8s.io,resources=selfnoderemediationconfigs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=self-node-remediation.medik8s.io,resources=selfnoderemediationconfigs/finalizers,verbs=update
@@ -55,6 +71,7 @@ type SelfNodeRemediationConfigReconciler struct {
 //+kubebuilder:rbac:groups="security.openshift.io",resources=securitycontextconstraints,verbs=use,resourceNames=privileged
 //+kubebuilder:rbac:groups=machine.openshift.io,resources=machines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=machine.openshift.io,resources=machines/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *SelfNodeRemediationConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("selfnoderemediationconfig", req.NamespacedName)
@@ -85,7 +102,89 @@ func (r *SelfNodeRemediationConfigReconciler) Reconcile(ctx context.Context, req
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	result, err := r.syncRemediationSchedule(config)
+	if err != nil {
+		logger.Error(err, "error syncing remediation schedule")
+	}
+	return result, err
+}
+
+// syncRemediationSchedule updates the RemediationsHeld condition according to the remediation schedule, and emits an
+// event whenever it changes. The held remediations themselves are handled by the agents.
+func (r *SelfNodeRemediationConfigReconciler) syncRemediationSchedule(config *selfnoderemediationv1alpha1.SelfNodeRemediationConfig) (ctrl.Result, error) {
+	now := time.Now()
+	held, reason, message, err := getRemediationScheduleState(config.Spec.RemediationSchedule, now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := metav1.ConditionFalse
+	if held {
+		status = metav1.ConditionTrue
+	}
+	if setCondition(&config.Status.Conditions, selfnoderemediationv1alpha1.RemediationsHeldConditionType, status, reason, message) {
+		if err := r.Client.Status().Update(context.Background(), config); err != nil {
+			if errors.IsConflict(err) {
+				return ctrl.Result{RequeueAfter: time.Second}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(config, eventTypeNormal, eventReasonRemediationScheduleChanged, message)
+	}
+
+	if remediationSchedule := config.Spec.RemediationSchedule; remediationSchedule == nil ||
+		len(remediationSchedule.MaintenanceWindows) == 0 && len(remediationSchedule.BlackoutWindows) == 0 {
+		return ctrl.Result{}, nil
+	}
+	// windows start and end on minute boundaries
+	return ctrl.Result{RequeueAfter: now.Truncate(time.Minute).Add(time.Minute).Sub(now)}, nil
+}
+
+// getRemediationScheduleState returns whether the given schedule holds remediations at the given time, and the
+// reason and message of the RemediationsHeld condition
+func getRemediationScheduleState(s *selfnoderemediationv1alpha1.RemediationSchedule, now time.Time) (bool, string, string, error) {
+	if s == nil {
+		return false, selfnoderemediationv1alpha1.RemediationsAllowedReason, "Remediations may start at any time", nil
+	}
+
+	if s.Paused {
+		return true, selfnoderemediationv1alpha1.RemediationsPausedReason, "Remediations are paused", nil
+	}
+
+	now = now.UTC()
+	for _, window := range s.BlackoutWindows {
+		isActive, err := isWindowActive(window, now)
+		if err != nil {
+			return false, "", "", err
+		}
+		if isActive {
+			return true, selfnoderemediationv1alpha1.BlackoutWindowReason,
+				fmt.Sprintf("Remediations are held during blackout window %s", window.Name), nil
+		}
+	}
+
+	if len(s.MaintenanceWindows) == 0 {
+		return false, selfnoderemediationv1alpha1.RemediationsAllowedReason, "Remediations may start outside of blackout windows", nil
+	}
+	for _, window := range s.MaintenanceWindows {
+		isActive, err := isWindowActive(window, now)
+		if err != nil {
+			return false, "", "", err
+		}
+		if isActive {
+			return false, selfnoderemediationv1alpha1.RemediationsAllowedReason,
+				fmt.Sprintf("Remediations may start during maintenance window %s", window.Name), nil
+		}
+	}
+	return true, selfnoderemediationv1alpha1.OutsideMaintenanceWindowReason, "Remediations are held until the next maintenance window", nil
+}
+
+func isWindowActive(window selfnoderemediationv1alpha1.ScheduleWindow, now time.Time) (bool, error) {
+	cron, err := schedule.ParseCron(window.Schedule)
+	if err != nil {
+		return false, fmt.Errorf("invalid schedule of window %s: %v", window.Name, err)
+	}
+	return cron.IsInWindow(now, window.Duration.Duration), nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -96,6 +195,67 @@ func (r *SelfNodeRemediationConfigReconciler) SetupWithManager(mgr ctrl.Manager)
 		Complete(r)
 }
 
+// durationOrZero returns the duration of optional fields, which might be nil
+func durationOrZero(d *metav1.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.Duration
+}
+
+// intOrStringOrEmpty returns the string value of optional fields, which might be nil
+func intOrStringOrEmpty(v *intstr.IntOrString) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+// getEndpointHealthChecks returns the endpoint health checks as JSON, including the deprecated EndpointHealthCheckUrl
+// as an ICMP endpoint. It returns an empty string when there are no endpoints.
+func getEndpointHealthChecks(snrConfig *selfnoderemediationv1alpha1.SelfNodeRemediationConfig) (string, error) {
+	checks := &selfnoderemediationv1alpha1.EndpointHealthChecks{}
+	if snrConfig.Spec.EndpointHealthChecks != nil {
+		checks = snrConfig.Spec.EndpointHealthChecks.DeepCopy()
+	}
+	if snrConfig.Spec.EndpointHealthCheckUrl != "" {
+		checks.Endpoints = append(checks.Endpoints, selfnoderemediationv1alpha1.HealthCheckEndpoint{
+			Type:    selfnoderemediationv1alpha1.ICMPEndpointHealthCheckType,
+			Address: snrConfig.Spec.EndpointHealthCheckUrl,
+		})
+	}
+	if len(checks.Endpoints) == 0 {
+		return "", nil
+	}
+
+	value, err := json.Marshal(checks)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// getSelfDiagnostics returns the self diagnostics as JSON, or an empty string when they aren't configured
+func getSelfDiagnostics(snrConfig *selfnoderemediationv1alpha1.SelfNodeRemediationConfig) (string, error) {
+	if snrConfig.Spec.SelfDiagnostics == nil {
+		return "", nil
+	}
+	value, err := json.Marshal(snrConfig.Spec.SelfDiagnostics)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// getRebootMethods returns the comma separated reboot methods, or an empty string for the default ones
+func getRebootMethods(snrConfig *selfnoderemediationv1alpha1.SelfNodeRemediationConfig) string {
+	methods := make([]string, len(snrConfig.Spec.RebootMethods))
+	for i, method := range snrConfig.Spec.RebootMethods {
+		methods[i] = string(method)
+	}
+	return strings.Join(methods, ",")
+}
+
 func (r *SelfNodeRemediationConfigReconciler) syncConfigDaemonSet(snrConfig *selfnoderemediationv1alpha1.SelfNodeRemediationConfig) error {
 	logger := r.Log.WithName("syncConfigDaemonset")
 	logger.Info("Start to sync config daemonset")
@@ -110,6 +270,12 @@ func (r *SelfNodeRemediationConfigReconciler) syncConfigDaemonSet(snrConfig *sel
 	}
 	data.Data["WatchdogPath"] = watchdogPath
 
+	etcdCertsPath := snrConfig.Spec.EtcdCertsPath
+	if etcdCertsPath == "" {
+		etcdCertsPath = selfnoderemediationv1alpha1.DefaultEtcdCertsPath
+	}
+	data.Data["EtcdCertsPath"] = etcdCertsPath
+
 	data.Data["PeerApiServerTimeout"] = snrConfig.Spec.PeerApiServerTimeout.Nanoseconds()
 	data.Data["ApiCheckInterval"] = snrConfig.Spec.ApiCheckInterval.Nanoseconds()
 	data.Data["PeerUpdateInterval"] = snrConfig.Spec.PeerUpdateInterval.Nanoseconds()
@@ -117,7 +283,34 @@ func (r *SelfNodeRemediationConfigReconciler) syncConfigDaemonSet(snrConfig *sel
 	data.Data["PeerDialTimeout"] = snrConfig.Spec.PeerDialTimeout.Nanoseconds()
 	data.Data["PeerRequestTimeout"] = snrConfig.Spec.PeerRequestTimeout.Nanoseconds()
 	data.Data["MaxApiErrorThreshold"] = snrConfig.Spec.MaxApiErrorThreshold
-	data.Data["EndpointHealthCheckUrl"] = snrConfig.Spec.EndpointHealthCheckUrl
+	endpointHealthChecks, err := getEndpointHealthChecks(snrConfig)
+	if err != nil {
+		logger.Error(err, "Fail to marshal endpoint health checks")
+		return err
+	}
+	data.Data["EndpointHealthChecks"] = strconv.Quote(endpointHealthChecks)
+	selfDiagnostics, err := getSelfDiagnostics(snrConfig)
+	if err != nil {
+		logger.Error(err, "Fail to marshal self diagnostics")
+		return err
+	}
+	data.Data["SelfDiagnostics"] = strconv.Quote(selfDiagnostics)
+	selfInitiatedRemediationFailureDuration := time.Duration(0)
+	selfInitiatedRemediationStrategy := ""
+	if selfInitiated := snrConfig.Spec.SelfInitiatedRemediation; selfInitiated != nil {
+		selfInitiatedRemediationFailureDuration = durationOrZero(selfInitiated.FailureDuration)
+		if selfInitiatedRemediationFailureDuration == 0 {
+			selfInitiatedRemediationFailureDuration = defaultSelfInitiatedRemediationFailureDuration
+		}
+		selfInitiatedRemediationStrategy = string(selfInitiated.RemediationStrategy)
+	}
+	data.Data["SelfInitiatedRemediationFailureDuration"] = selfInitiatedRemediationFailureDuration.Nanoseconds()
+	data.Data["SelfInitiatedRemediationStrategy"] = selfInitiatedRemediationStrategy
+	data.Data["RemediationTimeout"] = durationOrZero(snrConfig.Spec.RemediationTimeout).Nanoseconds()
+	data.Data["MaxRemediationsPerNode"] = snrConfig.Spec.MaxRemediationsPerNode
+	data.Data["RemediationsWindow"] = durationOrZero(snrConfig.Spec.RemediationsWindow).Nanoseconds()
+	data.Data["MaxConcurrentRemediations"] = intOrStringOrEmpty(snrConfig.Spec.MaxConcurrentRemediations)
+	data.Data["RemediationStormThreshold"] = intOrStringOrEmpty(snrConfig.Spec.RemediationStormThreshold)
 
 	timeToAssumeNodeRebooted := snrConfig.Spec.SafeTimeToAssumeNodeRebootedSeconds
 	if timeToAssumeNodeRebooted == 0 {
@@ -126,6 +319,15 @@ func (r *SelfNodeRemediationConfigReconciler) syncConfigDaemonSet(snrConfig *sel
 	data.Data["TimeToAssumeNodeRebooted"] = fmt.Sprintf("\"%d\"", timeToAssumeNodeRebooted)
 
 	data.Data["IsSoftwareRebootEnabled"] = fmt.Sprintf("\"%t\"", snrConfig.Spec.IsSoftwareRebootEnabled)
+	data.Data["RebootMethods"] = getRebootMethods(snrConfig)
+	data.Data["SysRqSync"] = fmt.Sprintf("\"%t\"", snrConfig.Spec.SysRqSync)
+	data.Data["DiagnosticsBundlePath"] = snrConfig.Spec.DiagnosticsBundlePath
+	data.Data["PingBeforeFencing"] = fmt.Sprintf("\"%t\"", snrConfig.Spec.PingBeforeFencing)
+	maxFencingPostponement := defaultMaxFencingPostponement
+	if snrConfig.Spec.MaxFencingPostponement != nil {
+		maxFencingPostponement = snrConfig.Spec.MaxFencingPostponement.Duration
+	}
+	data.Data["MaxFencingPostponement"] = maxFencingPostponement.Nanoseconds()
 
 	objs, err := render.Dir(r.InstallFileFolder, &data)
 	if err != nil {
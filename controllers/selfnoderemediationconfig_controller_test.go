@@ -2,6 +2,7 @@ package controllers_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
@@ -10,7 +11,10 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	selfnoderemediationv1alpha1 "github.com/medik8s/self-node-remediation/api/v1alpha1"
@@ -28,6 +32,24 @@ var _ = Describe("snrc controller Test", func() {
 		config.APIVersion = "self-node-remediation.medik8s.io/v1alpha1"
 		config.Spec.WatchdogFilePath = "/dev/foo"
 		config.Spec.SafeTimeToAssumeNodeRebootedSeconds = 123
+		config.Spec.RemediationTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+		config.Spec.MaxRemediationsPerNode = 3
+		maxConcurrent := intstr.FromString("20%")
+		config.Spec.MaxConcurrentRemediations = &maxConcurrent
+		config.Spec.EndpointHealthCheckUrl = "10.0.0.1"
+		ntpSyncWeight := 2
+		config.Spec.SelfDiagnostics = &selfnoderemediationv1alpha1.SelfDiagnostics{
+			Checks: []selfnoderemediationv1alpha1.DiagnosticsCheck{
+				{Name: selfnoderemediationv1alpha1.NTPSyncDiagnosticsCheck, Weight: &ntpSyncWeight},
+			},
+			FailureThreshold: 2,
+		}
+		config.Spec.RebootMethods = []selfnoderemediationv1alpha1.RebootMethod{
+			selfnoderemediationv1alpha1.WatchdogRebootMethod, selfnoderemediationv1alpha1.SysRqRebootMethod,
+		}
+		config.Spec.SysRqSync = true
+		config.Spec.DiagnosticsBundlePath = "/var/log/self-node-remediation"
+		config.Spec.PingBeforeFencing = true
 		config.Name = selfnoderemediationv1alpha1.ConfigCRName
 		config.Namespace = namespace
 
@@ -70,11 +92,78 @@ var _ = Describe("snrc controller Test", func() {
 			envVars := getEnvVarMap(container.Env)
 			Expect(envVars["WATCHDOG_PATH"].Value).To(Equal(config.Spec.WatchdogFilePath))
 			Expect(envVars["TIME_TO_ASSUME_NODE_REBOOTED"].Value).To(Equal("123"))
+			Expect(envVars["REMEDIATION_TIMEOUT"].Value).To(Equal(fmt.Sprint((10 * time.Minute).Nanoseconds())))
+			Expect(envVars["MAX_REMEDIATIONS_PER_NODE"].Value).To(Equal("3"))
+			Expect(envVars["REMEDIATIONS_WINDOW"].Value).To(Equal(fmt.Sprint((24 * time.Hour).Nanoseconds())))
+			Expect(envVars["MAX_CONCURRENT_REMEDIATIONS"].Value).To(Equal("20%"))
+			Expect(envVars["REMEDIATION_STORM_THRESHOLD"].Value).To(BeEmpty())
+			Expect(envVars["ENDPOINT_HEALTH_CHECKS"].Value).To(MatchJSON(`{"endpoints":[{"type":"ICMP","address":"10.0.0.1"}]}`))
+			// the check is enabled by default
+			Expect(envVars["SELF_DIAGNOSTICS"].Value).To(MatchJSON(`{"checks":[{"name":"NTPSync","enabled":true,"weight":2}],"failureThreshold":2}`))
+			Expect(envVars["REBOOT_METHODS"].Value).To(Equal("Watchdog,SysRq"))
+			Expect(envVars["SYSRQ_SYNC"].Value).To(Equal("true"))
+			Expect(envVars["DIAGNOSTICS_BUNDLE_PATH"].Value).To(Equal("/var/log/self-node-remediation"))
+			Expect(envVars["PING_BEFORE_FENCING"].Value).To(Equal("true"))
+			// the default is used when it's not set
+			Expect(envVars["MAX_FENCING_POSTPONEMENT"].Value).To(Equal(fmt.Sprint((10 * time.Minute).Nanoseconds())))
+
+			var etcdCertsVolume *corev1.Volume
+			for i := range ds.Spec.Template.Spec.Volumes {
+				if ds.Spec.Template.Spec.Volumes[i].Name == "etcd-certs" {
+					etcdCertsVolume = &ds.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(etcdCertsVolume).ToNot(BeNil())
+			Expect(etcdCertsVolume.HostPath.Path).To(Equal(selfnoderemediationv1alpha1.DefaultEtcdCertsPath))
+			// the certificates exist only on control-plane nodes, an empty directory is created on all other nodes
+			// so that the agent can start there, and the etcd health check is skipped since it has no certificates
+			Expect(etcdCertsVolume.HostPath.Type).ToNot(BeNil())
+			Expect(*etcdCertsVolume.HostPath.Type).To(Equal(corev1.HostPathDirectoryOrCreate))
 
 			Expect(len(ds.OwnerReferences)).To(Equal(1))
 			Expect(ds.OwnerReferences[0].Name).To(Equal(config.Name))
 			Expect(ds.OwnerReferences[0].Kind).To(Equal("SelfNodeRemediationConfig"))
 		})
+
+		It("RemediationsHeld condition should follow the remediation schedule", func() {
+			configKey := client.ObjectKeyFromObject(config)
+			updateSchedule := func(remediationSchedule *selfnoderemediationv1alpha1.RemediationSchedule) {
+				Eventually(func() error {
+					updatedConfig := &selfnoderemediationv1alpha1.SelfNodeRemediationConfig{}
+					if err := k8sClient.Get(context.Background(), configKey, updatedConfig); err != nil {
+						return err
+					}
+					updatedConfig.Spec.RemediationSchedule = remediationSchedule
+					return k8sClient.Update(context.Background(), updatedConfig)
+				}, 5*time.Second, 250*time.Millisecond).Should(Succeed())
+			}
+			verifyCondition := func(status metav1.ConditionStatus, reason string) {
+				Eventually(func() (*metav1.Condition, error) {
+					updatedConfig := &selfnoderemediationv1alpha1.SelfNodeRemediationConfig{}
+					err := k8sClient.Get(context.Background(), configKey, updatedConfig)
+					return meta.FindStatusCondition(updatedConfig.Status.Conditions, selfnoderemediationv1alpha1.RemediationsHeldConditionType), err
+				}, 5*time.Second, 250*time.Millisecond).Should(And(
+					Not(BeNil()),
+					WithTransform(func(c *metav1.Condition) metav1.ConditionStatus { return c.Status }, Equal(status)),
+					WithTransform(func(c *metav1.Condition) string { return c.Reason }, Equal(reason)),
+				))
+			}
+
+			updateSchedule(&selfnoderemediationv1alpha1.RemediationSchedule{
+				MaintenanceWindows: []selfnoderemediationv1alpha1.ScheduleWindow{{
+					Name:     "always",
+					Schedule: "* * * * *",
+					Duration: metav1.Duration{Duration: time.Hour},
+				}},
+			})
+			verifyCondition(metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationsAllowedReason)
+
+			updateSchedule(&selfnoderemediationv1alpha1.RemediationSchedule{Paused: true})
+			verifyCondition(metav1.ConditionTrue, selfnoderemediationv1alpha1.RemediationsPausedReason)
+
+			updateSchedule(nil)
+			verifyCondition(metav1.ConditionFalse, selfnoderemediationv1alpha1.RemediationsAllowedReason)
+		})
 	})
 
 	Context("SNRC defaults", func() {
@@ -105,6 +194,17 @@ var _ = Describe("snrc controller Test", func() {
 			Expect(createdConfig.Spec.ApiServerTimeout.Seconds()).To(BeEquivalentTo(5))
 			Expect(createdConfig.Spec.ApiCheckInterval.Seconds()).To(BeEquivalentTo(15))
 			Expect(createdConfig.Spec.PeerUpdateInterval.Seconds()).To(BeEquivalentTo(15 * 60))
+			Expect(createdConfig.Spec.RemediationTimeout).To(BeNil())
+			Expect(createdConfig.Spec.MaxRemediationsPerNode).To(BeZero())
+			Expect(createdConfig.Spec.RemediationsWindow.Hours()).To(BeEquivalentTo(24))
+			Expect(createdConfig.Spec.MaxConcurrentRemediations).To(BeNil())
+			Expect(createdConfig.Spec.RemediationStormThreshold).To(BeNil())
+			Expect(createdConfig.Spec.EtcdCertsPath).To(Equal(selfnoderemediationv1alpha1.DefaultEtcdCertsPath))
+			Expect(createdConfig.Spec.SelfDiagnostics).To(BeNil())
+			Expect(createdConfig.Spec.RebootMethods).To(BeEmpty())
+			Expect(createdConfig.Spec.DiagnosticsBundlePath).To(BeEmpty())
+			Expect(createdConfig.Spec.PingBeforeFencing).To(BeFalse())
+			Expect(createdConfig.Spec.MaxFencingPostponement).To(BeNil())
 		})
 	})
 
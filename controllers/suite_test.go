@@ -20,15 +20,19 @@ import (
 	"context"
 	"errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	machinev1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
@@ -56,6 +60,7 @@ var certReader certificates.CertStorageReader
 var unhealthyNode = &v1.Node{}
 var peerNode = &v1.Node{}
 var cancelFunc context.CancelFunc
+var nodeProber = &fakeNodeProber{}
 
 var unhealthyNodeNamespacedName = client.ObjectKey{
 	Name:      unhealthyNodeName,
@@ -74,6 +79,19 @@ const (
 	namespace         = "self-node-remediation"
 	unhealthyNodeName = "node1"
 	peerNodeName      = "node2"
+
+	// the remediation history is cleared after each test, so this limit affects only tests which set the history
+	maxRemediationsPerNode = 2
+	// both limits are higher than the number of remediations in tests which don't create additional SNRs
+	maxConcurrentRemediations = 2
+	remediationStormThreshold = 4
+
+	// it's longer than the postponement of fencing in tests which confirm the reboot eventually
+	maxFencingPostponement = 20 * time.Second
+
+	// the agents read the remediation schedule from a config in another namespace than the one of the config
+	// controller tests, so that remediations are held only by the remediation schedule tests
+	scheduleConfigNamespace = "default"
 )
 
 type K8sClientWrapper struct {
@@ -89,6 +107,33 @@ func (kcw *K8sClientWrapper) List(ctx context.Context, list client.ObjectList, o
 	return kcw.Client.List(ctx, list, opts...)
 }
 
+// fakeNodeProber answers with the boot ID which was set, by default the probed node doesn't answer and isn't reachable
+type fakeNodeProber struct {
+	mutex  sync.Mutex
+	bootID string
+}
+
+var _ controllers.NodeProber = &fakeNodeProber{}
+
+func (p *fakeNodeProber) setBootID(bootID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.bootID = bootID
+}
+
+func (p *fakeNodeProber) GetBootInfo(_ *v1.Node) (string, time.Duration, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.bootID == "" {
+		return "", 0, errors.New("simulation of a node which doesn't answer")
+	}
+	return p.bootID, time.Hour, nil
+}
+
+func (p *fakeNodeProber) IsReachable(_ *v1.Node) bool {
+	return false
+}
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -102,7 +147,10 @@ var _ = BeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "testdata", "crds"),
+		},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -113,6 +161,9 @@ var _ = BeforeSuite(func() {
 	err = selfnoderemediationv1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = machinev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sManager, err := ctrl.NewManager(cfg, ctrl.Options{
@@ -136,12 +187,16 @@ var _ = BeforeSuite(func() {
 
 	Expect(k8sClient.Create(context.Background(), nsToCreate)).To(Succeed())
 
+	// the fake recorder blocks when its buffer is full, so it needs to be big enough for all tests
+	fakeRecorder := record.NewFakeRecorder(100)
+
 	err = (&controllers.SelfNodeRemediationConfigReconciler{
 		Client:            k8sManager.GetClient(),
 		Log:               ctrl.Log.WithName("controllers").WithName("self-node-remediation-config-controller"),
 		InstallFileFolder: "../install/",
 		Scheme:            scheme.Scheme,
 		Namespace:         namespace,
+		Recorder:          fakeRecorder,
 	}).SetupWithManager(k8sManager)
 
 	// peers need their own node on start
@@ -182,15 +237,24 @@ var _ = BeforeSuite(func() {
 	timeToAssumeNodeRebooted += 5 * time.Second
 
 	restoreNodeAfter := 5 * time.Second
+	maxConcurrent := intstr.FromInt(maxConcurrentRemediations)
+	stormThreshold := intstr.FromInt(remediationStormThreshold)
 
 	// reconciler for unhealthy node
 	err = (&controllers.SelfNodeRemediationReconciler{
 		Client:                       k8sClient,
 		Log:                          ctrl.Log.WithName("controllers").WithName("self-node-remediation-controller").WithName("unhealthy node"),
+		APIReader:                    k8sClient.Reader,
 		Rebooter:                     rebooter,
 		SafeTimeToAssumeNodeRebooted: timeToAssumeNodeRebooted,
 		MyNodeName:                   unhealthyNodeName,
 		RestoreNodeAfter:             restoreNodeAfter,
+		Recorder:                     fakeRecorder,
+		MaxRemediationsPerNode:       maxRemediationsPerNode,
+		RemediationsWindow:           time.Hour,
+		MaxConcurrentRemediations:    &maxConcurrent,
+		RemediationStormThreshold:    &stormThreshold,
+		ConfigNamespace:              scheduleConfigNamespace,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -198,9 +262,18 @@ var _ = BeforeSuite(func() {
 	err = (&controllers.SelfNodeRemediationReconciler{
 		Client:                       k8sClient,
 		Log:                          ctrl.Log.WithName("controllers").WithName("self-node-remediation-controller").WithName("peer node"),
+		APIReader:                    k8sClient.Reader,
 		SafeTimeToAssumeNodeRebooted: timeToAssumeNodeRebooted,
 		MyNodeName:                   peerNodeName,
 		RestoreNodeAfter:             restoreNodeAfter,
+		Recorder:                     fakeRecorder,
+		MaxRemediationsPerNode:       maxRemediationsPerNode,
+		RemediationsWindow:           time.Hour,
+		MaxConcurrentRemediations:    &maxConcurrent,
+		RemediationStormThreshold:    &stormThreshold,
+		ConfigNamespace:              scheduleConfigNamespace,
+		NodeProber:                   nodeProber,
+		MaxFencingPostponement:       maxFencingPostponement,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -21,6 +21,7 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -31,6 +32,7 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -46,8 +48,11 @@ import (
 	"github.com/medik8s/self-node-remediation/pkg/apicheck"
 	"github.com/medik8s/self-node-remediation/pkg/certificates"
 	"github.com/medik8s/self-node-remediation/pkg/controlplane"
+	"github.com/medik8s/self-node-remediation/pkg/diagnostics"
+	"github.com/medik8s/self-node-remediation/pkg/kubelet"
 	"github.com/medik8s/self-node-remediation/pkg/peerhealth"
 	"github.com/medik8s/self-node-remediation/pkg/peers"
+	"github.com/medik8s/self-node-remediation/pkg/postmortem"
 	"github.com/medik8s/self-node-remediation/pkg/reboot"
 	"github.com/medik8s/self-node-remediation/pkg/snrconfighelper"
 	"github.com/medik8s/self-node-remediation/pkg/utils"
@@ -57,6 +62,7 @@ import (
 
 const (
 	nodeNameEnvVar            = "MY_NODE_NAME"
+	nodeIPEnvVar              = "MY_NODE_IP"
 	peerHealthDefaultPort     = 30001
 	maxTimeForNoPeersResponse = 30 * time.Second
 )
@@ -153,6 +159,7 @@ func initSelfNodeRemediationManager(mgr manager.Manager) {
 		InstallFileFolder: "./install",
 		DefaultPpcCreator: snrconfighelper.NewConfigIfNotExist,
 		Namespace:         ns,
+		Recorder:          mgr.GetEventRecorderFor("SelfNodeRemediationConfig"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SelfNodeRemediationConfig")
 		os.Exit(1)
@@ -187,6 +194,52 @@ func getIntEnvVarOrDie(varName string) int {
 	return intVar
 }
 
+// getIntOrStringEnvVar returns nil if the env variable is empty
+func getIntOrStringEnvVar(varName string) *intstr.IntOrString {
+	varVal := os.Getenv(varName)
+	if varVal == "" {
+		return nil
+	}
+	val := intstr.Parse(varVal)
+	return &val
+}
+
+// newRebooter returns a rebooter with the configured reboot methods, software reboot methods are skipped when
+// software reboots are disabled
+func newRebooter(wd watchdog.Watchdog) reboot.Rebooter {
+	softwareRebootEnabled, err := utils.IsSoftwareRebootEnabled()
+	if err != nil {
+		setupLog.Error(err, "failed to check if software reboots are enabled")
+		os.Exit(1)
+	}
+	// an empty value means that the filesystems aren't synced
+	sysRqSync, _ := strconv.ParseBool(os.Getenv("SYSRQ_SYNC"))
+
+	names := reboot.DefaultMethods
+	if value := os.Getenv("REBOOT_METHODS"); value != "" {
+		names = nil
+		for _, name := range strings.Split(value, ",") {
+			names = append(names, selfnoderemediationv1alpha1.RebootMethod(name))
+		}
+	}
+
+	var methods []reboot.Method
+	for _, name := range names {
+		if name != selfnoderemediationv1alpha1.WatchdogRebootMethod && !softwareRebootEnabled {
+			setupLog.Info("software reboot is disabled, skipping reboot method", "method", name)
+			continue
+		}
+		method, err := reboot.NewMethod(name, wd, sysRqSync)
+		if err != nil {
+			setupLog.Error(err, "failed to init reboot method", "method", name)
+			os.Exit(1)
+		}
+		methods = append(methods, method)
+	}
+	setupLog.Info("reboot methods", "methods", names, "software reboot enabled", softwareRebootEnabled)
+	return reboot.NewRebooter(wd, methods, ctrl.Log.WithName("rebooter"))
+}
+
 func initSelfNodeRemediationAgent(mgr manager.Manager) {
 	setupLog.Info("Starting as a self node remediation agent that should run as part of the daemonset")
 
@@ -221,8 +274,34 @@ func initSelfNodeRemediationAgent(mgr manager.Manager) {
 		os.Exit(1)
 	}
 
+	if err = utils.InitOutOfServiceTaintSupportedFlag(mgr.GetConfig()); err != nil {
+		setupLog.Error(err, "failed to check if out-of-service taint is supported")
+		os.Exit(1)
+	}
+	setupLog.Info("out-of-service taint support", "supported", utils.IsOutOfServiceTaintSupported)
+
+	// the marker tells whether the node was taken down by its agent, and why
+	rebootMarker := postmortem.NewRebootMarker()
+	rebootReporter := postmortem.NewRebootReporter(rebootMarker, myNodeName, mgr.GetAPIReader(), mgr.GetClient(),
+		mgr.GetEventRecorderFor("SelfNodeRemediation"), ctrl.Log.WithName("postmortem"))
+	if err = mgr.Add(rebootReporter); err != nil {
+		setupLog.Error(err, "failed to add the reboot reporter to the manager")
+		os.Exit(1)
+	}
+
 	// it's fine when the watchdog is nil!
-	rebooter := reboot.NewWatchdogRebooter(wd, ctrl.Log.WithName("rebooter"))
+	rebooter := newRebooter(wd)
+
+	// the diagnostic bundle is written before the node is taken down, and reported after it's up again
+	postMortem := postmortem.NewCollector(os.Getenv("DIAGNOSTICS_BUNDLE_PATH"), myNodeName, mgr.GetClient(),
+		mgr.GetEventRecorderFor("SelfNodeRemediation"), ctrl.Log.WithName("postmortem"))
+	if postMortem != nil {
+		if err = mgr.Add(postMortem); err != nil {
+			setupLog.Error(err, "failed to add the diagnostic bundle collector to the manager")
+			os.Exit(1)
+		}
+		rebooter = postmortem.NewRebooter(rebooter, postMortem)
+	}
 
 	// TODO make the interval configurable
 	peerUpdateInterval := getDurEnvVarOrDie("PEER_UPDATE_INTERVAL")
@@ -241,6 +320,8 @@ func initSelfNodeRemediationAgent(mgr manager.Manager) {
 	peerDialTimeout := getDurEnvVarOrDie("PEER_DIAL_TIMEOUT")         //timeout for establishing connection to peer
 	peerRequestTimeout := getDurEnvVarOrDie("PEER_REQUEST_TIMEOUT")   //timeout for each peer request
 
+	kubeletChecker := kubelet.NewChecker(os.Getenv(nodeIPEnvVar), ctrl.Log.WithName("kubelet"))
+
 	// init certificate reader
 	certReader := certificates.NewSecretCertStorage(mgr.GetClient(), ctrl.Log.WithName("SecretCertStorage"), ns)
 
@@ -258,9 +339,25 @@ func initSelfNodeRemediationAgent(mgr manager.Manager) {
 		PeerRequestTimeout:        peerRequestTimeout,
 		PeerHealthPort:            peerHealthDefaultPort,
 		MaxTimeForNoPeersResponse: maxTimeForNoPeersResponse,
+		SelfInitiatedRemediation: apicheck.SelfInitiatedRemediationConfig{
+			FailureDuration: getDurEnvVarOrDie("SELF_INITIATED_REMEDIATION_FAILURE_DURATION"),
+			Strategy:        selfnoderemediationv1alpha1.RemediationStrategyType(os.Getenv("SELF_INITIATED_REMEDIATION_STRATEGY")),
+			Client:          mgr.GetClient(),
+			Namespace:       ns,
+		},
+		PostMortem:   postMortem,
+		RebootMarker: rebootMarker,
 	}
 
-	controlPlaneManager := controlplane.NewManager(myNodeName, mgr.GetClient())
+	// the endpoints and etcd checks are provided by the control plane manager
+	diagnosticsLog := ctrl.Log.WithName("diagnostics")
+	diagnosticsProviders := diagnostics.Providers{
+		selfnoderemediationv1alpha1.KubeletDiagnosticsCheck:          kubeletChecker,
+		selfnoderemediationv1alpha1.ContainerRuntimeDiagnosticsCheck: diagnostics.NewContainerRuntimeCheck(diagnosticsLog.WithName("container-runtime")),
+		selfnoderemediationv1alpha1.DiskPressureDiagnosticsCheck:     diagnostics.NewDiskPressureCheck(diagnosticsLog.WithName("disk-pressure")),
+		selfnoderemediationv1alpha1.NTPSyncDiagnosticsCheck:          diagnostics.NewNTPSyncCheck(diagnosticsLog.WithName("ntp-sync")),
+	}
+	controlPlaneManager := controlplane.NewManager(myNodeName, mgr.GetClient(), diagnosticsProviders)
 
 	if err = mgr.Add(controlPlaneManager); err != nil {
 		setupLog.Error(err, "failed to add controlPlane remediation manager to setup manager")
@@ -293,16 +390,38 @@ func initSelfNodeRemediationAgent(mgr manager.Manager) {
 	}
 	setupLog.Info("Time to assume that unhealthy node has been rebooted", "time", timeToAssumeNodeRebooted)
 
+	// an empty value means that the unhealthy node isn't pinged before it's fenced
+	pingBeforeFencing, _ := strconv.ParseBool(os.Getenv("PING_BEFORE_FENCING"))
+	nodeProber := peerhealth.NewProber(myPeers, certReader, peerHealthDefaultPort, peerDialTimeout, peerRequestTimeout,
+		pingBeforeFencing, ctrl.Log.WithName("peerhealth").WithName("prober"))
+
 	restoreNodeAfter := 90 * time.Second
+	remediationTimeout := getDurEnvVarOrDie("REMEDIATION_TIMEOUT")
+	maxFencingPostponement := getDurEnvVarOrDie("MAX_FENCING_POSTPONEMENT")
+	maxRemediationsPerNode := getIntEnvVarOrDie("MAX_REMEDIATIONS_PER_NODE")
+	remediationsWindow := getDurEnvVarOrDie("REMEDIATIONS_WINDOW")
+	maxConcurrentRemediations := getIntOrStringEnvVar("MAX_CONCURRENT_REMEDIATIONS")
+	remediationStormThreshold := getIntOrStringEnvVar("REMEDIATION_STORM_THRESHOLD")
 	snrReconciler := &controllers.SelfNodeRemediationReconciler{
 		Client:                       mgr.GetClient(),
 		Log:                          ctrl.Log.WithName("controllers").WithName("SelfNodeRemediation"),
 		Scheme:                       mgr.GetScheme(),
 		Recorder:                     mgr.GetEventRecorderFor("SelfNodeRemediation"),
 		Rebooter:                     rebooter,
+		APIReader:                    mgr.GetAPIReader(),
 		SafeTimeToAssumeNodeRebooted: timeToAssumeNodeRebooted,
 		MyNodeName:                   myNodeName,
 		RestoreNodeAfter:             restoreNodeAfter,
+		RemediationTimeout:           remediationTimeout,
+		MaxRemediationsPerNode:       maxRemediationsPerNode,
+		RemediationsWindow:           remediationsWindow,
+		MaxConcurrentRemediations:    maxConcurrentRemediations,
+		RemediationStormThreshold:    remediationStormThreshold,
+		ConfigNamespace:              ns,
+		PostMortem:                   postMortem,
+		RebootMarker:                 rebootMarker,
+		NodeProber:                   nodeProber,
+		MaxFencingPostponement:       maxFencingPostponement,
 	}
 
 	if err = snrReconciler.SetupWithManager(mgr); err != nil {
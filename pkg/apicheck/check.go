@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	v1 "k8s.io/api/core/v1"
@@ -17,10 +18,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	selfNodeRemediation "github.com/medik8s/self-node-remediation/api"
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
 	"github.com/medik8s/self-node-remediation/pkg/certificates"
 	"github.com/medik8s/self-node-remediation/pkg/controlplane"
 	"github.com/medik8s/self-node-remediation/pkg/peerhealth"
 	"github.com/medik8s/self-node-remediation/pkg/peers"
+	"github.com/medik8s/self-node-remediation/pkg/postmortem"
 	"github.com/medik8s/self-node-remediation/pkg/reboot"
 )
 
@@ -29,9 +32,11 @@ type ApiConnectivityCheck struct {
 	config                 *ApiConnectivityCheckConfig
 	errorCount             int
 	timeOfLastPeerResponse time.Time
-	clientCreds            credentials.TransportCredentials
-	mutex                  sync.Mutex
-	controlPlaneManager    *controlplane.Manager
+	// timeOfFirstDiagnosticsFailure is the start of the current self diagnostics failure while the api server is reachable
+	timeOfFirstDiagnosticsFailure time.Time
+	clientCreds                   credentials.TransportCredentials
+	mutex                         sync.Mutex
+	controlPlaneManager           *controlplane.Manager
 }
 
 type ApiConnectivityCheckConfig struct {
@@ -48,6 +53,22 @@ type ApiConnectivityCheckConfig struct {
 	PeerRequestTimeout        time.Duration
 	PeerHealthPort            int
 	MaxTimeForNoPeersResponse time.Duration
+	SelfInitiatedRemediation  SelfInitiatedRemediationConfig
+	// PostMortem collects the health decisions and peer responses for the diagnostic bundle, it may be nil
+	PostMortem *postmortem.Collector
+	// RebootMarker is written before the node is rebooted, it may be nil
+	RebootMarker *postmortem.RebootMarker
+}
+
+// SelfInitiatedRemediationConfig configures the remediations which the agent creates for its own node
+type SelfInitiatedRemediationConfig struct {
+	// FailureDuration is the time for which the self diagnostics must keep failing before the node remediates itself,
+	// 0 disables self-initiated remediations
+	FailureDuration time.Duration
+	Strategy        v1alpha1.RemediationStrategyType
+	// Client and Namespace are used for creating the SelfNodeRemediation
+	Client    client.Client
+	Namespace string
 }
 
 func New(config *ApiConnectivityCheckConfig, controlPlaneManager *controlplane.Manager) *ApiConnectivityCheck {
@@ -85,9 +106,12 @@ func (c *ApiConnectivityCheck) Start(ctx context.Context) error {
 		}
 		if failure != "" {
 			c.config.Log.Error(fmt.Errorf(failure), "failed to check api server")
-			if isHealthy := c.isConsideredHealthy(); !isHealthy {
+			if isHealthy, peersResponse := c.isConsideredHealthy(); !isHealthy {
 				// we have a problem on this node
 				c.config.Log.Error(err, "we are unhealthy, triggering a reboot")
+				if err := c.config.RebootMarker.Write(postmortem.RebootReason{Reason: string(peersResponse.Reason)}); err != nil {
+					c.config.Log.Error(err, "failed to write the reboot marker")
+				}
 				if err := c.config.Rebooter.Reboot(); err != nil {
 					c.config.Log.Error(err, "failed to trigger reboot")
 				}
@@ -100,6 +124,10 @@ func (c *ApiConnectivityCheck) Start(ctx context.Context) error {
 		// reset error count after a successful API call
 		c.errorCount = 0
 
+		// a broken kubelet isn't noticed by the api server check, since the api server is reachable, it fails the
+		// kubelet check of the self diagnostics
+		c.checkSelfInitiatedRemediation(ctx)
+
 	}, c.config.CheckInterval)
 
 	c.config.Log.Info("api connectivity check started")
@@ -109,16 +137,20 @@ func (c *ApiConnectivityCheck) Start(ctx context.Context) error {
 }
 
 // isConsideredHealthy keeps track of the number of errors reported, and when a certain amount of error occur within a certain
-// time, ask peers if this node is healthy. Returns if the node is considered to be healthy or not.
-func (c *ApiConnectivityCheck) isConsideredHealthy() bool {
+// time, ask peers if this node is healthy. Returns if the node is considered to be healthy or not, and the response
+// of the worker peers, which holds the reason.
+func (c *ApiConnectivityCheck) isConsideredHealthy() (bool, peers.Response) {
 	workerPeersResponse := c.getWorkerPeersResponse()
-	isWorkerNode := c.controlPlaneManager == nil || !c.controlPlaneManager.IsControlPlane()
-	if isWorkerNode {
-		return workerPeersResponse.IsHealthy
-	} else {
-		return c.controlPlaneManager.IsControlPlaneHealthy(workerPeersResponse, c.canOtherControlPlanesBeReached())
+	isHealthy := workerPeersResponse.IsHealthy
+	if c.controlPlaneManager != nil {
+		if !c.controlPlaneManager.IsControlPlane() {
+			isHealthy = c.controlPlaneManager.IsWorkerHealthy(workerPeersResponse)
+		} else {
+			isHealthy = c.controlPlaneManager.IsControlPlaneHealthy(workerPeersResponse, c.canOtherControlPlanesBeReached())
+		}
 	}
-
+	c.config.PostMortem.RecordDecision(isHealthy, string(workerPeersResponse.Reason))
+	return isHealthy, workerPeersResponse
 }
 
 func (c *ApiConnectivityCheck) getWorkerPeersResponse() peers.Response {
@@ -240,18 +272,27 @@ func (c *ApiConnectivityCheck) popNodes(nodes *[][]v1.NodeAddress, count int) []
 }
 
 func (c *ApiConnectivityCheck) getHealthStatusFromPeers(addresses []string) (int, int, int, int) {
+	return c.getStatusFromPeers(addresses, (*peerhealth.Client).IsHealthy)
+}
+
+// getStatusFromPeers sends the given request to the peers with the given addresses, and returns the number of
+// healthy, unhealthy, api error and failed responses
+func (c *ApiConnectivityCheck) getStatusFromPeers(addresses []string, request peerRequest) (int, int, int, int) {
 	nrAddresses := len(addresses)
 	responsesChan := make(chan selfNodeRemediation.HealthCheckResponseCode, nrAddresses)
 
 	for _, address := range addresses {
-		go c.getHealthStatusFromPeer(address, responsesChan)
+		go c.getHealthStatusFromPeer(address, request, responsesChan)
 	}
 
 	return c.sumPeersResponses(nrAddresses, responsesChan)
 }
 
-// getHealthStatusFromPeer issues a GET request to the specified IP and returns the result from the peer into the given channel
-func (c *ApiConnectivityCheck) getHealthStatusFromPeer(endpointIp string, results chan<- selfNodeRemediation.HealthCheckResponseCode) {
+// peerRequest is a request of the peer health service about this node
+type peerRequest func(*peerhealth.Client, context.Context, *peerhealth.HealthRequest, ...grpc.CallOption) (*peerhealth.HealthResponse, error)
+
+// getHealthStatusFromPeer issues the given request to the specified IP and returns the result from the peer into the given channel
+func (c *ApiConnectivityCheck) getHealthStatusFromPeer(endpointIp string, request peerRequest, results chan<- selfNodeRemediation.HealthCheckResponseCode) {
 
 	logger := c.config.Log.WithValues("IP", endpointIp)
 	logger.Info("getting health status from peer")
@@ -266,6 +307,7 @@ func (c *ApiConnectivityCheck) getHealthStatusFromPeer(endpointIp string, result
 	phClient, err := peerhealth.NewClient(fmt.Sprintf("%v:%v", endpointIp, c.config.PeerHealthPort), c.config.PeerDialTimeout, c.config.Log.WithName("peerhealth client"), c.clientCreds)
 	if err != nil {
 		logger.Error(err, "failed to init grpc client")
+		c.config.PostMortem.RecordPeerResponse(endpointIp, selfNodeRemediation.RequestFailed)
 		results <- selfNodeRemediation.RequestFailed
 		return
 	}
@@ -274,17 +316,19 @@ func (c *ApiConnectivityCheck) getHealthStatusFromPeer(endpointIp string, result
 	ctx, cancel := context.WithTimeout(context.Background(), c.config.PeerRequestTimeout)
 	defer cancel()
 
-	resp, err := phClient.IsHealthy(ctx, &peerhealth.HealthRequest{
+	resp, err := request(phClient, ctx, &peerhealth.HealthRequest{
 		NodeName: c.config.MyNodeName,
 	})
 	if err != nil {
 		logger.Error(err, "failed to read health response from peer")
+		c.config.PostMortem.RecordPeerResponse(endpointIp, selfNodeRemediation.RequestFailed)
 		results <- selfNodeRemediation.RequestFailed
 		return
 	}
 
 	logger.Info("got response from peer", "status", resp.Status)
 
+	c.config.PostMortem.RecordPeerResponse(endpointIp, selfNodeRemediation.HealthCheckResponseCode(resp.Status))
 	results <- selfNodeRemediation.HealthCheckResponseCode(resp.Status)
 	return
 }
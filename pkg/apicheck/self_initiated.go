@@ -0,0 +1,97 @@
+package apicheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/peerhealth"
+	"github.com/medik8s/self-node-remediation/pkg/peers"
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+)
+
+// maxConfirmingPeers is the number of peers which are asked to confirm a self-initiated remediation
+const maxConfirmingPeers = 3
+
+// checkSelfInitiatedRemediation remediates the node when its self diagnostics keep failing although the api server is
+// reachable, and its peers confirm that the node isn't ready
+func (c *ApiConnectivityCheck) checkSelfInitiatedRemediation(ctx context.Context) {
+	if c.config.SelfInitiatedRemediation.FailureDuration == 0 || c.controlPlaneManager == nil {
+		return
+	}
+
+	if c.controlPlaneManager.IsDiagnosticsPassed() {
+		c.timeOfFirstDiagnosticsFailure = time.Time{}
+		return
+	}
+
+	now := time.Now()
+	if c.timeOfFirstDiagnosticsFailure.IsZero() {
+		c.timeOfFirstDiagnosticsFailure = now
+	}
+	failureDuration := now.Sub(c.timeOfFirstDiagnosticsFailure)
+	if failureDuration < c.config.SelfInitiatedRemediation.FailureDuration {
+		c.config.Log.Info("self diagnostics failed although the api server is reachable", "failure duration (seconds)", failureDuration.Seconds(),
+			"threshold (seconds)", c.config.SelfInitiatedRemediation.FailureDuration.Seconds())
+		return
+	}
+
+	notReadyResponses, readyResponses := c.getNodeReadinessFromPeers()
+	if notReadyResponses == 0 || notReadyResponses <= readyResponses {
+		c.config.Log.Info("self diagnostics keep failing, but peers didn't confirm that the node isn't ready",
+			"not ready responses", notReadyResponses, "ready responses", readyResponses)
+		return
+	}
+
+	reason := fmt.Sprintf("self diagnostics failed for %s although the api server was reachable, %d of %d responding peers confirmed that the node isn't ready",
+		failureDuration.Round(time.Second), notReadyResponses, notReadyResponses+readyResponses)
+	if err := c.createSelfInitiatedRemediation(ctx, reason); err != nil {
+		c.config.Log.Error(err, "failed to create self-initiated remediation")
+	}
+}
+
+// getNodeReadinessFromPeers asks a few peers whether this node is ready, and returns the number of peers which
+// responded that it isn't ready and that it's ready
+func (c *ApiConnectivityCheck) getNodeReadinessFromPeers() (int, int) {
+	nodesToAsk := append(c.config.Peers.GetPeersAddresses(peers.Worker), c.config.Peers.GetPeersAddresses(peers.ControlPlane)...)
+	chosenNodesAddresses := c.popNodes(&nodesToAsk, maxConfirmingPeers)
+	readyResponses, notReadyResponses, _, _ := c.getStatusFromPeers(chosenNodesAddresses, (*peerhealth.Client).IsNodeReady)
+	return notReadyResponses, readyResponses
+}
+
+// createSelfInitiatedRemediation creates a SelfNodeRemediation for this node, unless there is one already
+func (c *ApiConnectivityCheck) createSelfInitiatedRemediation(ctx context.Context, reason string) error {
+	snrs := &v1alpha1.SelfNodeRemediationList{}
+	if err := c.config.SelfInitiatedRemediation.Client.List(ctx, snrs); err != nil {
+		return err
+	}
+	for _, snr := range snrs.Items {
+		if snr.Name == c.config.MyNodeName {
+			c.config.Log.Info("node is already being remediated", "namespace", snr.Namespace)
+			return nil
+		}
+	}
+
+	snr := &v1alpha1.SelfNodeRemediation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        c.config.MyNodeName,
+			Namespace:   c.config.SelfInitiatedRemediation.Namespace,
+			Annotations: map[string]string{utils.SelfInitiatedRemediationAnnotation: reason},
+		},
+		Spec: v1alpha1.SelfNodeRemediationSpec{
+			RemediationStrategy: c.config.SelfInitiatedRemediation.Strategy,
+		},
+	}
+	if err := c.config.SelfInitiatedRemediation.Client.Create(ctx, snr); err != nil {
+		if apiErrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	c.config.Log.Info("created self-initiated remediation", "reason", reason)
+	return nil
+}
@@ -3,46 +3,84 @@ package controlplane
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/go-ping/ping"
 
 	corev1 "k8s.io/api/core/v1"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/diagnostics"
+	"github.com/medik8s/self-node-remediation/pkg/endpoint"
 	"github.com/medik8s/self-node-remediation/pkg/peers"
 	"github.com/medik8s/self-node-remediation/pkg/utils"
 )
 
 const (
-	kubeletPort = "10250"
+	etcdPort = "2379"
+	// etcdCertsMountPath is where the host path of the etcd certificates is mounted in the agent's container
+	etcdCertsMountPath     = "/etc/etcd-certs"
+	etcdCaFile             = "ca.crt"
+	etcdClientCertFile     = "healthcheck-client.crt"
+	etcdClientKeyFile      = "healthcheck-client.key"
+	etcdHealthCheckTimeout = 5 * time.Second
 )
 
-// Manager contains logic and info needed to fence and remediate controlplane nodes
+// etcdHealth is the response of etcd's health endpoint
+type etcdHealth struct {
+	Health string `json:"health"`
+	Reason string `json:"reason"`
+}
+
+// Manager contains logic and info needed to fence and remediate controlplane nodes, and runs the self diagnostics
+// of all nodes
 type Manager struct {
 	nodeName                     string
 	nodeRole                     peers.Role
-	endpointHealthCheckUrl       string
+	endpointChecker              *endpoint.Checker
 	wasEndpointAccessibleAtStart bool
+	etcdHealthCheckUrl           string
+	etcdCertsDir                 string
+	diagnosticsConfig            *v1alpha1.SelfDiagnostics
+	diagnosticsProviders         diagnostics.Providers
+	diagnostics                  *diagnostics.Diagnostics
 	client                       client.Client
 	log                          logr.Logger
 }
 
-// NewManager inits a new Manager return nil if init fails
-func NewManager(nodeName string, myClient client.Client) *Manager {
+// NewManager inits a new Manager return nil if init fails. The providers provide the self diagnostics checks of the
+// node, in addition to the endpoints and etcd checks which the Manager provides
+func NewManager(nodeName string, myClient client.Client, providers diagnostics.Providers) *Manager {
+	log := ctrl.Log.WithName("controlPlane").WithName("Manager")
+	endpointHealthChecks, err := endpoint.ParseChecks(os.Getenv("ENDPOINT_HEALTH_CHECKS"))
+	if err != nil {
+		log.Error(err, "failed to parse endpoint health checks, they are ignored")
+	}
+	diagnosticsConfig, err := diagnostics.ParseConfig(os.Getenv("SELF_DIAGNOSTICS"))
+	if err != nil {
+		log.Error(err, "failed to parse self diagnostics, using the default checks")
+	}
+
 	return &Manager{
 		nodeName:                     nodeName,
-		endpointHealthCheckUrl:       os.Getenv("END_POINT_HEALTH_CHECK_URL"),
+		endpointChecker:              endpoint.NewChecker(endpointHealthChecks, ctrl.Log.WithName("endpoint")),
+		diagnosticsConfig:            diagnosticsConfig,
+		diagnosticsProviders:         providers,
 		client:                       myClient,
 		wasEndpointAccessibleAtStart: false,
-		log:                          ctrl.Log.WithName("controlPlane").WithName("Manager"),
+		etcdCertsDir:                 etcdCertsMountPath,
+		log:                          log,
 	}
 }
 
@@ -69,9 +107,9 @@ func (manager *Manager) IsControlPlaneHealthy(workerPeerResponse peers.Response,
 		return true
 	//controlPlane node has connection to most workers, we assume it's not isolated (or at least that the controlPlane node that does not have worker peers quorum will reboot)
 	case peers.HealthyBecauseMostPeersCantAccessAPIServer:
-		return manager.isDiagnosticsPassed()
+		return manager.IsDiagnosticsPassed()
 	case peers.HealthyBecauseNoPeersWereFound:
-		return manager.isDiagnosticsPassed() && canOtherControlPlanesBeReached
+		return manager.IsDiagnosticsPassed() && canOtherControlPlanesBeReached
 
 	default:
 		errorText := "node is considered unhealthy by worker peers for an unknown reason"
@@ -81,15 +119,20 @@ func (manager *Manager) IsControlPlaneHealthy(workerPeerResponse peers.Response,
 
 }
 
-func (manager *Manager) isDiagnosticsPassed() bool {
-	if manager.isEndpointAccessLost() {
-		return false
-	} else if !manager.isKubeletServiceRunning() {
-		return false
-	} else if !manager.isEtcdRunning() {
-		return false
+// IsWorkerHealthy decides whether a worker node is healthy. Self diagnostics decide when the peers don't tell whether
+// the node is healthy, because most of them can't access the api server either, or because there are no peers
+func (manager *Manager) IsWorkerHealthy(peerResponse peers.Response) bool {
+	switch peerResponse.Reason {
+	case peers.HealthyBecauseMostPeersCantAccessAPIServer, peers.HealthyBecauseNoPeersWereFound:
+		return manager.IsDiagnosticsPassed()
+	default:
+		return peerResponse.IsHealthy
 	}
-	return true
+}
+
+// IsDiagnosticsPassed runs the self diagnostics of the node, and returns true if they passed
+func (manager *Manager) IsDiagnosticsPassed() bool {
+	return manager.diagnostics.IsPassed()
 }
 
 func wrapWithInitError(err error) error {
@@ -108,11 +151,34 @@ func (manager *Manager) initializeManager() error {
 		return wrapWithInitError(err)
 	}
 	manager.setNodeRole(node)
+	manager.setEtcdHealthCheckUrl(node)
 
 	manager.wasEndpointAccessibleAtStart = manager.isEndpointAccessible()
+	manager.initializeDiagnostics()
 	return nil
 }
 
+// initializeDiagnostics sets up the self diagnostics of the node's role. Control-plane nodes use the default
+// control-plane checks when self diagnostics aren't configured, and etcd is checked on control-plane nodes only
+func (manager *Manager) initializeDiagnostics() {
+	providers := diagnostics.Providers{}
+	for name, check := range manager.diagnosticsProviders {
+		providers[name] = check
+	}
+	providers[v1alpha1.EndpointsDiagnosticsCheck] = diagnostics.CheckFunc(func() bool {
+		return !manager.isEndpointAccessLost()
+	})
+
+	config := manager.diagnosticsConfig
+	if manager.IsControlPlane() {
+		providers[v1alpha1.EtcdDiagnosticsCheck] = diagnostics.CheckFunc(manager.isEtcdRunning)
+		if config == nil {
+			config = diagnostics.DefaultControlPlaneConfig()
+		}
+	}
+	manager.diagnostics = diagnostics.New(config, providers, ctrl.Log.WithName("diagnostics"))
+}
+
 func (manager *Manager) setNodeRole(node corev1.Node) {
 	if utils.IsControlPlaneNode(&node) {
 		manager.nodeRole = peers.ControlPlane
@@ -121,6 +187,16 @@ func (manager *Manager) setNodeRole(node corev1.Node) {
 	}
 }
 
+// setEtcdHealthCheckUrl sets the health endpoint of the local etcd member, which listens on the node's internal IP
+func (manager *Manager) setEtcdHealthCheckUrl(node corev1.Node) {
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			manager.etcdHealthCheckUrl = fmt.Sprintf("https://%s/health", net.JoinHostPort(address.Address, etcdPort))
+			return
+		}
+	}
+}
+
 func (manager *Manager) isEndpointAccessLost() bool {
 	if !manager.wasEndpointAccessibleAtStart {
 		return false
@@ -129,48 +205,72 @@ func (manager *Manager) isEndpointAccessLost() bool {
 }
 
 func (manager *Manager) isEndpointAccessible() bool {
-	if len(manager.endpointHealthCheckUrl) == 0 {
-		return true
-	}
-
-	pinger, err := ping.NewPinger(manager.endpointHealthCheckUrl)
-	if err != nil {
-		manager.log.Error(err, "could not access endpoint", "endpoint URL", manager.endpointHealthCheckUrl)
-		return false
-	}
-	pinger.Count = 3
-	pinger.Timeout = time.Second * 5
-
-	if err := pinger.Run(); err != nil {
-		manager.log.Error(err, "could not access endpoint", "endpoint URL", manager.endpointHealthCheckUrl)
+	if !manager.endpointChecker.IsAccessible() {
+		manager.log.Info("endpoints aren't accessible", "node name", manager.nodeName)
 		return false
 	}
 	return true
 }
 
-func (manager *Manager) isKubeletServiceRunning() bool {
-	url := fmt.Sprintf("https://%s:%s/pods", manager.nodeName, kubeletPort)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// isEtcdRunning returns true if the local etcd member is healthy. etcd reports a member as healthy only when it has
+// a leader, which means that it's part of a healthy quorum
+func (manager *Manager) isEtcdRunning() bool {
+	if len(manager.etcdHealthCheckUrl) == 0 {
+		return true
 	}
-	httpClient := &http.Client{Transport: tr}
 
-	req, err := http.NewRequest("GET", url, nil)
+	caFile := filepath.Join(manager.etcdCertsDir, etcdCaFile)
+	if _, err := os.Stat(caFile); os.IsNotExist(err) {
+		manager.log.Info("etcd certificates not found, skipping etcd health check", "path", manager.etcdCertsDir)
+		return true
+	}
+
+	httpClient, err := manager.getEtcdHttpClient(caFile)
 	if err != nil {
-		manager.log.Error(err, "failed to create a kubelet service request", "node name", manager.nodeName)
+		manager.log.Error(err, "failed to create an etcd health check client", "path", manager.etcdCertsDir)
 		return false
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient.Get(manager.etcdHealthCheckUrl)
 	if err != nil {
-		manager.log.Error(err, "kubelet service is down", "node name", manager.nodeName)
+		manager.log.Error(err, "etcd is down", "node name", manager.nodeName)
 		return false
 	}
 	defer resp.Body.Close()
+
+	// etcd responds with 503 and the health details when the member is unhealthy
+	health := etcdHealth{}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		manager.log.Error(err, "failed to parse etcd health", "status code", resp.StatusCode)
+		return false
+	}
+	if health.Health != "true" {
+		manager.log.Info("etcd member is unhealthy", "node name", manager.nodeName, "reason", health.Reason)
+		return false
+	}
 	return true
 }
 
-func (manager *Manager) isEtcdRunning() bool {
-	//TODO mshitrit implement
-	return true
+func (manager *Manager) getEtcdHttpClient(caFile string) (*http.Client, error) {
+	caPem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, fmt.Errorf("failed to append etcd ca cert")
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(filepath.Join(manager.etcdCertsDir, etcdClientCertFile), filepath.Join(manager.etcdCertsDir, etcdClientKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			Certificates: []tls.Certificate{keyPair},
+			RootCAs:      pool,
+		},
+	}
+	return &http.Client{Transport: tr, Timeout: etcdHealthCheckTimeout}, nil
 }
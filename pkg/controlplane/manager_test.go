@@ -0,0 +1,180 @@
+package controlplane
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/diagnostics"
+	"github.com/medik8s/self-node-remediation/pkg/endpoint"
+	"github.com/medik8s/self-node-remediation/pkg/peers"
+)
+
+func TestIsEtcdRunning(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	certsDir := t.TempDir()
+	serverCert, pool := createEtcdCerts(g, certsDir)
+
+	health := `{"health":"true","reason":""}`
+	statusCode := http.StatusOK
+	// fake etcd health endpoint, which requires a client certificate like etcd does
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(health))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	manager := &Manager{
+		nodeName:           "control-plane-1",
+		etcdHealthCheckUrl: server.URL + "/health",
+		etcdCertsDir:       certsDir,
+		log:                ctrl.Log.WithName("controlPlane").WithName("Manager"),
+	}
+	g.Expect(manager.isEtcdRunning()).To(BeTrue())
+
+	// the member lost the quorum
+	health = `{"health":"false","reason":"RAFT NO LEADER"}`
+	statusCode = http.StatusServiceUnavailable
+	g.Expect(manager.isEtcdRunning()).To(BeFalse())
+
+	// the check is skipped when the certificates aren't found
+	manager.etcdCertsDir = filepath.Join(certsDir, "missing")
+	g.Expect(manager.isEtcdRunning()).To(BeTrue())
+
+	// etcd is down
+	manager.etcdCertsDir = certsDir
+	server.Close()
+	g.Expect(manager.isEtcdRunning()).To(BeFalse())
+}
+
+func TestSetEtcdHealthCheckUrl(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	manager := &Manager{}
+	manager.setEtcdHealthCheckUrl(corev1.Node{})
+	g.Expect(manager.etcdHealthCheckUrl).To(BeEmpty())
+
+	node := corev1.Node{
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "control-plane-1"},
+				{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+			},
+		},
+	}
+	manager.setEtcdHealthCheckUrl(node)
+	g.Expect(manager.etcdHealthCheckUrl).To(Equal("https://[fd00::1]:2379/health"))
+}
+
+// createEtcdCerts writes a CA and a client certificate to the given dir like the etcd static pod certificates,
+// and returns a server certificate signed by the same CA
+func createEtcdCerts(g *WithT, dir string) (tls.Certificate, *x509.CertPool) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).ToNot(HaveOccurred())
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "etcd-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	caCert, err := x509.ParseCertificate(caDer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "etcd"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer})
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	g.Expect(os.WriteFile(filepath.Join(dir, etcdCaFile), caPem, 0600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, etcdClientCertFile), certPem, 0600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, etcdClientKeyFile), keyPem, 0600)).To(Succeed())
+
+	serverCert, err := tls.X509KeyPair(certPem, keyPem)
+	g.Expect(err).ToNot(HaveOccurred())
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	return serverCert, pool
+}
+
+func TestIsWorkerHealthy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeletHealthy := true
+	manager := &Manager{
+		nodeName: "worker-1",
+		nodeRole: peers.Worker,
+		diagnosticsConfig: &v1alpha1.SelfDiagnostics{
+			Checks: []v1alpha1.DiagnosticsCheck{{Name: v1alpha1.KubeletDiagnosticsCheck}, {Name: v1alpha1.EtcdDiagnosticsCheck}},
+		},
+		diagnosticsProviders: diagnostics.Providers{
+			v1alpha1.KubeletDiagnosticsCheck: diagnostics.CheckFunc(func() bool { return kubeletHealthy }),
+		},
+		endpointChecker: endpoint.NewChecker(nil, ctrl.Log.WithName("endpoint")),
+		log:             ctrl.Log.WithName("controlPlane").WithName("Manager"),
+	}
+	manager.initializeDiagnostics()
+
+	mostPeersCantAccessAPIServer := peers.Response{IsHealthy: true, Reason: peers.HealthyBecauseMostPeersCantAccessAPIServer}
+	g.Expect(manager.IsWorkerHealthy(mostPeersCantAccessAPIServer)).To(BeTrue())
+
+	kubeletHealthy = false
+	g.Expect(manager.IsWorkerHealthy(mostPeersCantAccessAPIServer)).To(BeFalse())
+	g.Expect(manager.IsWorkerHealthy(peers.Response{IsHealthy: true, Reason: peers.HealthyBecauseNoPeersWereFound})).To(BeFalse())
+
+	// peers which tell whether the node is healthy override the diagnostics
+	g.Expect(manager.IsWorkerHealthy(peers.Response{IsHealthy: true, Reason: peers.HealthyBecauseCRNotFound})).To(BeTrue())
+	g.Expect(manager.IsWorkerHealthy(peers.Response{IsHealthy: false, Reason: peers.UnHealthyBecausePeersResponse})).To(BeFalse())
+
+	// workers don't check etcd, and have no default checks
+	kubeletHealthy = true
+	g.Expect(manager.diagnostics.IsPassed()).To(BeTrue())
+	kubeletHealthy = false
+	manager.diagnosticsConfig = nil
+	manager.initializeDiagnostics()
+	g.Expect(manager.IsWorkerHealthy(mostPeersCantAccessAPIServer)).To(BeTrue())
+}
@@ -0,0 +1,120 @@
+package diagnostics
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/sys/unix"
+
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+)
+
+const (
+	// hostRoot is the root filesystem of the host, which is accessible since the agent runs with hostPID
+	hostRoot = "/proc/1/root"
+	// minAvailableDiskRatio is the default hard eviction threshold of the kubelet for the node's filesystem
+	minAvailableDiskRatio = 0.1
+	dialTimeout           = 5 * time.Second
+)
+
+// containerRuntimeSockets are the default sockets of CRI-O and containerd
+var containerRuntimeSockets = []string{"/run/crio/crio.sock", "/run/containerd/containerd.sock"}
+
+// ContainerRuntimeCheck checks that the socket of the container runtime of the host accepts connections
+type ContainerRuntimeCheck struct {
+	sockets []string
+	log     logr.Logger
+}
+
+// NewContainerRuntimeCheck returns a ContainerRuntimeCheck for the default sockets of CRI-O and containerd
+func NewContainerRuntimeCheck(log logr.Logger) *ContainerRuntimeCheck {
+	sockets := make([]string, len(containerRuntimeSockets))
+	for i, socket := range containerRuntimeSockets {
+		sockets[i] = filepath.Join(hostRoot, socket)
+	}
+	return &ContainerRuntimeCheck{sockets: sockets, log: log}
+}
+
+// IsHealthy returns true if a container runtime socket accepts connections, or if none of the sockets exists
+func (c *ContainerRuntimeCheck) IsHealthy() bool {
+	found := false
+	for _, socket := range c.sockets {
+		if _, err := os.Stat(socket); err != nil {
+			continue
+		}
+		found = true
+		conn, err := net.DialTimeout("unix", socket, dialTimeout)
+		if err != nil {
+			c.log.Error(err, "failed to connect to the container runtime socket", "socket", socket)
+			continue
+		}
+		_ = conn.Close()
+		return true
+	}
+
+	if !found {
+		c.log.Info("container runtime socket not found, skipping container runtime check")
+		return true
+	}
+	return false
+}
+
+// DiskPressureCheck checks the available space of the root filesystem of the host
+type DiskPressureCheck struct {
+	path string
+	log  logr.Logger
+}
+
+// NewDiskPressureCheck returns a DiskPressureCheck for the root filesystem of the host
+func NewDiskPressureCheck(log logr.Logger) *DiskPressureCheck {
+	return &DiskPressureCheck{path: hostRoot, log: log}
+}
+
+// IsHealthy returns true if at least 10% of the root filesystem is available
+func (c *DiskPressureCheck) IsHealthy() bool {
+	stat := unix.Statfs_t{}
+	if err := unix.Statfs(c.path, &stat); err != nil {
+		c.log.Error(err, "failed to get the root filesystem stats")
+		return false
+	}
+	if stat.Blocks == 0 {
+		return true
+	}
+
+	availableRatio := float64(stat.Bavail) / float64(stat.Blocks)
+	if availableRatio < minAvailableDiskRatio {
+		c.log.Info("root filesystem is under disk pressure", "available percentage", int(availableRatio*100))
+		return false
+	}
+	return true
+}
+
+// NTPSyncCheck checks that the system clock of the host is synchronized
+type NTPSyncCheck struct {
+	// runHostCommand runs a command in the host's mount namespace
+	runHostCommand func(name string, args ...string) ([]byte, error)
+	log            logr.Logger
+}
+
+// NewNTPSyncCheck returns a NTPSyncCheck which asks systemd-timedated of the host
+func NewNTPSyncCheck(log logr.Logger) *NTPSyncCheck {
+	return &NTPSyncCheck{runHostCommand: utils.RunHostCommand, log: log}
+}
+
+// IsHealthy returns true if the system clock is synchronized
+func (c *NTPSyncCheck) IsHealthy() bool {
+	out, err := c.runHostCommand("/usr/bin/timedatectl", "show", "--property=NTPSynchronized", "--value")
+	if err != nil {
+		c.log.Error(err, "failed to check the system clock synchronization")
+		return false
+	}
+	if strings.TrimSpace(string(out)) != "yes" {
+		c.log.Info("system clock isn't synchronized")
+		return false
+	}
+	return true
+}
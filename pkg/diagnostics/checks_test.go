@@ -0,0 +1,67 @@
+package diagnostics
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestContainerRuntimeCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	socket := filepath.Join(t.TempDir(), "crio.sock")
+	check := &ContainerRuntimeCheck{
+		sockets: []string{socket},
+		log:     ctrl.Log.WithName("container-runtime"),
+	}
+
+	// the check is skipped when there is no socket
+	g.Expect(check.IsHealthy()).To(BeTrue())
+
+	listener, err := net.Listen("unix", socket)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(check.IsHealthy()).To(BeTrue())
+
+	// the socket file remains when the runtime is down
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	g.Expect(listener.Close()).To(Succeed())
+	g.Expect(check.IsHealthy()).To(BeFalse())
+}
+
+func TestDiskPressureCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	check := &DiskPressureCheck{path: t.TempDir(), log: ctrl.Log.WithName("disk-pressure")}
+	// can't assume anything about the available space, but the stats can be read
+	check.IsHealthy()
+
+	check.path = filepath.Join(check.path, "missing")
+	g.Expect(check.IsHealthy()).To(BeFalse())
+}
+
+func TestNTPSyncCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var out string
+	var err error
+	check := &NTPSyncCheck{
+		runHostCommand: func(name string, args ...string) ([]byte, error) {
+			return []byte(out), err
+		},
+		log: ctrl.Log.WithName("ntp-sync"),
+	}
+
+	out = "yes\n"
+	g.Expect(check.IsHealthy()).To(BeTrue())
+
+	out = "no\n"
+	g.Expect(check.IsHealthy()).To(BeFalse())
+
+	out, err = "", errors.New("exit status 1")
+	g.Expect(check.IsHealthy()).To(BeFalse())
+}
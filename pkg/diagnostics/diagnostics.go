@@ -0,0 +1,132 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+)
+
+const defaultWeight = 1
+
+// Check is a self diagnostics check of the local node
+type Check interface {
+	// IsHealthy returns true if the check passed
+	IsHealthy() bool
+}
+
+// CheckFunc adapts a function to a Check
+type CheckFunc func() bool
+
+// IsHealthy calls f
+func (f CheckFunc) IsHealthy() bool {
+	return f()
+}
+
+// Providers provide the checks which are available on the local node by their name
+type Providers map[v1alpha1.DiagnosticsCheckName]Check
+
+type weightedCheck struct {
+	name   v1alpha1.DiagnosticsCheckName
+	check  Check
+	weight int
+}
+
+// Diagnostics runs the enabled checks, and fails when the total weight of the failed checks reaches the failure threshold
+type Diagnostics struct {
+	checks           []weightedCheck
+	failureThreshold int
+	log              logr.Logger
+}
+
+// New returns Diagnostics with the enabled checks of the config, which can be nil. Checks which the providers don't
+// provide are ignored
+func New(config *v1alpha1.SelfDiagnostics, providers Providers, log logr.Logger) *Diagnostics {
+	d := &Diagnostics{
+		failureThreshold: 1,
+		log:              log,
+	}
+	if config == nil {
+		return d
+	}
+	if config.FailureThreshold > 0 {
+		d.failureThreshold = config.FailureThreshold
+	}
+
+	for _, c := range config.Checks {
+		if c.Enabled != nil && !*c.Enabled {
+			continue
+		}
+		check, exists := providers[c.Name]
+		if !exists {
+			log.Info("self diagnostics check isn't available on this node, ignoring it", "check", c.Name)
+			continue
+		}
+		weight := defaultWeight
+		if c.Weight != nil {
+			weight = *c.Weight
+		}
+		d.checks = append(d.checks, weightedCheck{name: c.Name, check: check, weight: weight})
+	}
+	return d
+}
+
+// DefaultControlPlaneConfig returns the checks of control-plane nodes when self diagnostics aren't configured,
+// which fail when any of them fails
+func DefaultControlPlaneConfig() *v1alpha1.SelfDiagnostics {
+	return &v1alpha1.SelfDiagnostics{
+		Checks: []v1alpha1.DiagnosticsCheck{
+			{Name: v1alpha1.EndpointsDiagnosticsCheck},
+			{Name: v1alpha1.KubeletDiagnosticsCheck},
+			{Name: v1alpha1.EtcdDiagnosticsCheck},
+		},
+		FailureThreshold: 1,
+	}
+}
+
+// ParseConfig parses self diagnostics from their JSON representation, an empty value returns nil
+func ParseConfig(value string) (*v1alpha1.SelfDiagnostics, error) {
+	if value == "" {
+		return nil, nil
+	}
+	config := &v1alpha1.SelfDiagnostics{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// IsPassed runs the checks and returns true if the total weight of the failed checks is below the failure threshold.
+// It returns true when there are no checks
+func (d *Diagnostics) IsPassed() bool {
+	if d == nil || len(d.checks) == 0 {
+		return true
+	}
+
+	results := make([]bool, len(d.checks))
+	wg := sync.WaitGroup{}
+	for i := range d.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = d.checks[i].check.IsHealthy()
+		}(i)
+	}
+	wg.Wait()
+
+	failedWeight := 0
+	for i, healthy := range results {
+		if !healthy {
+			d.log.Info("self diagnostics check failed", "check", d.checks[i].name, "weight", d.checks[i].weight)
+			failedWeight += d.checks[i].weight
+		}
+	}
+
+	if failedWeight >= d.failureThreshold {
+		d.log.Info("self diagnostics failed", "failed weight", failedWeight, "failure threshold", d.failureThreshold)
+		return false
+	}
+	return true
+}
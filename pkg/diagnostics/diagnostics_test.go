@@ -0,0 +1,81 @@
+package diagnostics
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+)
+
+func TestIsPassed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeletHealthy, ntpSynced, diskHealthy := true, true, true
+	providers := Providers{
+		v1alpha1.KubeletDiagnosticsCheck:      CheckFunc(func() bool { return kubeletHealthy }),
+		v1alpha1.NTPSyncDiagnosticsCheck:      CheckFunc(func() bool { return ntpSynced }),
+		v1alpha1.DiskPressureDiagnosticsCheck: CheckFunc(func() bool { return diskHealthy }),
+	}
+	log := ctrl.Log.WithName("diagnostics")
+
+	// no checks
+	g.Expect(New(nil, providers, log).IsPassed()).To(BeTrue())
+	g.Expect((*Diagnostics)(nil).IsPassed()).To(BeTrue())
+
+	disabled := false
+	ntpWeight, diskWeight := 0, 2
+	d := New(&v1alpha1.SelfDiagnostics{
+		Checks: []v1alpha1.DiagnosticsCheck{
+			{Name: v1alpha1.KubeletDiagnosticsCheck},
+			{Name: v1alpha1.NTPSyncDiagnosticsCheck, Weight: &ntpWeight},
+			{Name: v1alpha1.DiskPressureDiagnosticsCheck, Weight: &diskWeight},
+			{Name: v1alpha1.ContainerRuntimeDiagnosticsCheck, Enabled: &disabled},
+			// not provided
+			{Name: v1alpha1.EtcdDiagnosticsCheck},
+		},
+		FailureThreshold: 2,
+	}, providers, log)
+	g.Expect(d.checks).To(HaveLen(3))
+	g.Expect(d.IsPassed()).To(BeTrue())
+
+	// a check with weight 0 is only logged
+	ntpSynced = false
+	g.Expect(d.IsPassed()).To(BeTrue())
+
+	// below the failure threshold
+	kubeletHealthy = false
+	g.Expect(d.IsPassed()).To(BeTrue())
+
+	// the failure threshold is reached
+	diskHealthy = false
+	g.Expect(d.IsPassed()).To(BeFalse())
+	kubeletHealthy = true
+	g.Expect(d.IsPassed()).To(BeFalse())
+
+	// any failure fails the default control-plane checks
+	d = New(DefaultControlPlaneConfig(), providers, log)
+	g.Expect(d.IsPassed()).To(BeTrue())
+	kubeletHealthy = false
+	g.Expect(d.IsPassed()).To(BeFalse())
+}
+
+func TestParseConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config, err := ParseConfig("")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config).To(BeNil())
+
+	config, err = ParseConfig(`{"checks":[{"name":"Kubelet","enabled":true,"weight":2}],"failureThreshold":3}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.FailureThreshold).To(Equal(3))
+	g.Expect(config.Checks).To(HaveLen(1))
+	g.Expect(config.Checks[0].Name).To(Equal(v1alpha1.KubeletDiagnosticsCheck))
+	g.Expect(*config.Checks[0].Weight).To(Equal(2))
+
+	_, err = ParseConfig("{")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,172 @@
+package endpoint
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-ping/ping"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+)
+
+const (
+	checkTimeout = 5 * time.Second
+	// serviceAccountCaFile is trusted in addition to the system CAs, since it signs e.g. the api server's certificates
+	serviceAccountCaFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// Checker checks whether endpoints are accessible according to the policy of the endpoint health checks
+type Checker struct {
+	checks     *v1alpha1.EndpointHealthChecks
+	httpClient *http.Client
+	log        logr.Logger
+}
+
+// NewChecker returns a Checker for the given endpoint health checks, which can be nil
+func NewChecker(checks *v1alpha1.EndpointHealthChecks, log logr.Logger) *Checker {
+	return &Checker{
+		checks:     checks,
+		httpClient: newHttpClient(log),
+		log:        log,
+	}
+}
+
+// ParseChecks parses endpoint health checks from their JSON representation, an empty value returns nil
+func ParseChecks(value string) (*v1alpha1.EndpointHealthChecks, error) {
+	if value == "" {
+		return nil, nil
+	}
+	checks := &v1alpha1.EndpointHealthChecks{}
+	if err := json.Unmarshal([]byte(value), checks); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// HasEndpoints returns true if there are endpoints to check
+func (c *Checker) HasEndpoints() bool {
+	return c.checks != nil && len(c.checks.Endpoints) > 0
+}
+
+// IsAccessible returns true if enough endpoints are accessible according to the policy, or if there are no endpoints
+func (c *Checker) IsAccessible() bool {
+	if !c.HasEndpoints() {
+		return true
+	}
+
+	results := make([]bool, len(c.checks.Endpoints))
+	wg := sync.WaitGroup{}
+	for i := range c.checks.Endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.isEndpointAccessible(c.checks.Endpoints[i])
+		}(i)
+	}
+	wg.Wait()
+
+	accessible := 0
+	for _, result := range results {
+		if result {
+			accessible++
+		}
+	}
+
+	switch c.checks.Policy {
+	case v1alpha1.AllEndpointHealthCheckPolicy:
+		return accessible == len(results)
+	case v1alpha1.MajorityEndpointHealthCheckPolicy:
+		return accessible > len(results)/2
+	default:
+		return accessible > 0
+	}
+}
+
+func (c *Checker) isEndpointAccessible(endpoint v1alpha1.HealthCheckEndpoint) bool {
+	var err error
+	switch endpoint.Type {
+	case v1alpha1.ICMPEndpointHealthCheckType:
+		err = PingAddress(endpoint.Address)
+	case v1alpha1.TCPEndpointHealthCheckType:
+		err = c.dial(endpoint.Address)
+	case v1alpha1.HTTPEndpointHealthCheckType:
+		err = c.get(endpoint.Address, endpoint.ExpectedStatusCode)
+	default:
+		err = errors.New("unknown endpoint type")
+	}
+
+	if err != nil {
+		c.log.Error(err, "could not access endpoint", "type", endpoint.Type, "address", endpoint.Address)
+		return false
+	}
+	return true
+}
+
+// PingAddress returns an error if the given address doesn't reply to pings
+func PingAddress(address string) error {
+	pinger, err := ping.NewPinger(address)
+	if err != nil {
+		return err
+	}
+	pinger.Count = 3
+	pinger.Timeout = checkTimeout
+	if err := pinger.Run(); err != nil {
+		return err
+	}
+	// the pinger doesn't fail when there are no replies
+	if pinger.Statistics().PacketsRecv == 0 {
+		return errors.New("no ping replies")
+	}
+	return nil
+}
+
+func (c *Checker) dial(address string) error {
+	conn, err := net.DialTimeout("tcp", address, checkTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (c *Checker) get(address string, expectedStatusCode int) error {
+	if expectedStatusCode == 0 {
+		expectedStatusCode = http.StatusOK
+	}
+
+	resp, err := c.httpClient.Get(address)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		return fmt.Errorf("unexpected status code %d, expected %d", resp.StatusCode, expectedStatusCode)
+	}
+	return nil
+}
+
+func newHttpClient(log logr.Logger) *http.Client {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if caPem, err := os.ReadFile(serviceAccountCaFile); err == nil {
+		pool.AppendCertsFromPEM(caPem)
+	} else if !os.IsNotExist(err) {
+		log.Error(err, "failed to read the service account ca cert")
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+	return &http.Client{Transport: tr, Timeout: checkTimeout}
+}
@@ -0,0 +1,73 @@
+package endpoint
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+)
+
+func TestIsAccessible(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			http.NotFound(w, r)
+			return
+		}
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(closedListener.Close()).To(Succeed())
+	defer listener.Close()
+
+	accessibleHttp := v1alpha1.HealthCheckEndpoint{Type: v1alpha1.HTTPEndpointHealthCheckType, Address: server.URL + "/readyz"}
+	accessibleTcp := v1alpha1.HealthCheckEndpoint{Type: v1alpha1.TCPEndpointHealthCheckType, Address: listener.Addr().String()}
+	notFoundHttp := v1alpha1.HealthCheckEndpoint{Type: v1alpha1.HTTPEndpointHealthCheckType, Address: server.URL + "/healthz"}
+	expectedNotFoundHttp := v1alpha1.HealthCheckEndpoint{Type: v1alpha1.HTTPEndpointHealthCheckType, Address: server.URL + "/healthz",
+		ExpectedStatusCode: http.StatusNotFound}
+	inaccessibleTcp := v1alpha1.HealthCheckEndpoint{Type: v1alpha1.TCPEndpointHealthCheckType, Address: closedListener.Addr().String()}
+
+	newChecker := func(policy v1alpha1.EndpointHealthCheckPolicy, endpoints ...v1alpha1.HealthCheckEndpoint) *Checker {
+		checker := NewChecker(&v1alpha1.EndpointHealthChecks{Endpoints: endpoints, Policy: policy}, ctrl.Log.WithName("endpoint"))
+		checker.httpClient = server.Client()
+		return checker
+	}
+
+	g.Expect(NewChecker(nil, ctrl.Log.WithName("endpoint")).IsAccessible()).To(BeTrue())
+
+	g.Expect(newChecker("", inaccessibleTcp, accessibleHttp).IsAccessible()).To(BeTrue())
+	g.Expect(newChecker(v1alpha1.AnyEndpointHealthCheckPolicy, inaccessibleTcp, notFoundHttp).IsAccessible()).To(BeFalse())
+
+	g.Expect(newChecker(v1alpha1.AllEndpointHealthCheckPolicy, accessibleTcp, accessibleHttp, expectedNotFoundHttp).IsAccessible()).To(BeTrue())
+	g.Expect(newChecker(v1alpha1.AllEndpointHealthCheckPolicy, accessibleTcp, accessibleHttp, notFoundHttp).IsAccessible()).To(BeFalse())
+
+	g.Expect(newChecker(v1alpha1.MajorityEndpointHealthCheckPolicy, accessibleTcp, accessibleHttp, inaccessibleTcp).IsAccessible()).To(BeTrue())
+	g.Expect(newChecker(v1alpha1.MajorityEndpointHealthCheckPolicy, accessibleTcp, notFoundHttp, inaccessibleTcp, accessibleHttp).IsAccessible()).To(BeFalse())
+}
+
+func TestParseChecks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	checks, err := ParseChecks("")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(checks).To(BeNil())
+
+	checks, err = ParseChecks(`{"endpoints":[{"type":"TCP","address":"10.0.0.1:6443"}],"policy":"Majority"}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(checks.Policy).To(Equal(v1alpha1.MajorityEndpointHealthCheckPolicy))
+	g.Expect(checks.Endpoints).To(ConsistOf(v1alpha1.HealthCheckEndpoint{Type: v1alpha1.TCPEndpointHealthCheckType, Address: "10.0.0.1:6443"}))
+
+	_, err = ParseChecks("10.0.0.1")
+	g.Expect(err).To(HaveOccurred())
+}
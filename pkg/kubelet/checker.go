@@ -0,0 +1,132 @@
+package kubelet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+)
+
+const (
+	kubeletPort        = "10250"
+	healthCheckTimeout = 5 * time.Second
+	serviceAccountDir  = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// the kubelet authorizes requests to its healthz endpoint with the nodes/proxy subresource
+//+kubebuilder:rbac:groups=core,resources=nodes/proxy,verbs=get
+
+// Checker checks the health of the local kubelet
+type Checker struct {
+	healthzUrl string
+	caFile     string
+	tokenFile  string
+	// runHostCommand runs a command in the host's mount namespace
+	runHostCommand func(name string, args ...string) ([]byte, error)
+	log            logr.Logger
+}
+
+// NewChecker returns a Checker for the kubelet which listens on the given node IP. It authenticates with the
+// service account of the agent
+func NewChecker(nodeIP string, log logr.Logger) *Checker {
+	return &Checker{
+		healthzUrl:     fmt.Sprintf("https://%s/healthz", net.JoinHostPort(nodeIP, kubeletPort)),
+		caFile:         serviceAccountDir + "/ca.crt",
+		tokenFile:      serviceAccountDir + "/token",
+		runHostCommand: utils.RunHostCommand,
+		log:            log,
+	}
+}
+
+// IsHealthy returns true if the kubelet is healthy. It checks the kubelet's healthz endpoint, and falls back to
+// the state of the kubelet systemd unit when the endpoint can't be checked, e.g. because it isn't reachable or
+// the request isn't authorized
+func (c *Checker) IsHealthy() bool {
+	healthy, err := c.checkHealthz()
+	if err == nil {
+		return healthy
+	}
+	c.log.Error(err, "failed to check the kubelet healthz endpoint, checking the kubelet systemd unit", "url", c.healthzUrl)
+
+	active, err := c.isSystemdUnitActive()
+	if err != nil {
+		c.log.Error(err, "failed to check the kubelet systemd unit")
+		return false
+	}
+	if !active {
+		c.log.Info("kubelet systemd unit isn't active")
+	}
+	return active
+}
+
+// checkHealthz returns the result of the kubelet's healthz endpoint, or an error if the result can't be determined
+func (c *Checker) checkHealthz() (bool, error) {
+	httpClient, err := c.getHttpClient()
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.healthzUrl, nil)
+	if err != nil {
+		return false, err
+	}
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, fmt.Errorf("kubelet healthz request isn't authorized, status code: %d", resp.StatusCode)
+	default:
+		// the response lists the failed checks
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		c.log.Info("kubelet is unhealthy", "status code", resp.StatusCode, "response", string(body))
+		return false, nil
+	}
+}
+
+func (c *Checker) getHttpClient() (*http.Client, error) {
+	caPem, err := os.ReadFile(c.caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, fmt.Errorf("failed to append kubelet ca cert")
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+	return &http.Client{Transport: tr, Timeout: healthCheckTimeout}, nil
+}
+
+// isSystemdUnitActive returns true if the kubelet systemd unit of the host is active
+func (c *Checker) isSystemdUnitActive() (bool, error) {
+	// systemctl exits with a non-zero code when the unit isn't active, but still prints its state
+	out, err := c.runHostCommand("/bin/systemctl", "is-active", "kubelet")
+	state := strings.TrimSpace(string(out))
+	if state == "" && err != nil {
+		return false, err
+	}
+	return state == "active", nil
+}
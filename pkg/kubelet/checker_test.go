@@ -0,0 +1,75 @@
+package kubelet
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestIsHealthy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	statusCode := http.StatusOK
+	// fake kubelet healthz endpoint
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	tokenFile := filepath.Join(dir, "token")
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	g.Expect(os.WriteFile(caFile, caPem, 0600)).To(Succeed())
+	g.Expect(os.WriteFile(tokenFile, []byte("test-token\n"), 0600)).To(Succeed())
+
+	unitState := "active"
+	hostCommands := 0
+	checker := &Checker{
+		healthzUrl: server.URL + "/healthz",
+		caFile:     caFile,
+		tokenFile:  tokenFile,
+		runHostCommand: func(name string, args ...string) ([]byte, error) {
+			hostCommands++
+			if unitState != "active" {
+				return []byte(unitState + "\n"), errors.New("exit status 3")
+			}
+			return []byte(unitState + "\n"), nil
+		},
+		log: ctrl.Log.WithName("kubelet"),
+	}
+
+	g.Expect(checker.IsHealthy()).To(BeTrue())
+
+	// a failed healthz check is final
+	statusCode = http.StatusInternalServerError
+	g.Expect(checker.IsHealthy()).To(BeFalse())
+	g.Expect(hostCommands).To(BeZero())
+
+	// falls back to the systemd unit when the request isn't authorized
+	statusCode = http.StatusOK
+	g.Expect(os.WriteFile(tokenFile, []byte("other-token"), 0600)).To(Succeed())
+	g.Expect(checker.IsHealthy()).To(BeTrue())
+	g.Expect(hostCommands).To(Equal(1))
+
+	unitState = "inactive"
+	g.Expect(checker.IsHealthy()).To(BeFalse())
+
+	// falls back to the systemd unit when the kubelet isn't reachable
+	server.Close()
+	unitState = "active"
+	g.Expect(checker.IsHealthy()).To(BeTrue())
+	g.Expect(hostCommands).To(Equal(3))
+}
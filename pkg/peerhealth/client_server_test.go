@@ -7,8 +7,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/medik8s/self-node-remediation/api"
 	"github.com/medik8s/self-node-remediation/api/v1alpha1"
@@ -94,6 +96,10 @@ var _ = Describe("Checking health using grpc client and server", func() {
 			}, 5*time.Second, 250*time.Millisecond).Should(BeTrue(), "SNR not reconciled")
 		})
 
+		AfterEach(func() {
+			deleteSnr(nodeName)
+		})
+
 		It("should return unhealthy", func() {
 
 			By("calling isHealthy")
@@ -109,4 +115,193 @@ var _ = Describe("Checking health using grpc client and server", func() {
 
 	})
 
+	Describe("for an unhealthy node whose remediation is on hold", func() {
+
+		BeforeEach(func() {
+			By("creating a dry run SNR")
+			snr := &v1alpha1.SelfNodeRemediation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      nodeName,
+					Namespace: "default",
+				},
+				Spec: v1alpha1.SelfNodeRemediationSpec{
+					DryRun: true,
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), snr)).To(Succeed())
+
+			// wait until reconciled
+			Eventually(func() bool {
+				return pprr.GetLastSeenSnrNamespace() != ""
+			}, 5*time.Second, 250*time.Millisecond).Should(BeTrue(), "SNR not reconciled")
+		})
+
+		AfterEach(func() {
+			deleteSnr(nodeName)
+		})
+
+		It("should return healthy", func() {
+			verifyHealthResponse(phClient, api.Healthy)
+		})
+
+	})
+
+	Describe("for an unhealthy machine", func() {
+
+		var machineAPIVersion, machineAnnotation, machineAnnotationValue string
+
+		JustBeforeEach(func() {
+			By("adding the machine annotation to the node")
+			setNodeAnnotation(machineAnnotation, machineAnnotationValue)
+
+			By("creating a SNR owned by the machine")
+			snr := &v1alpha1.SelfNodeRemediation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      machineName,
+					Namespace: "default",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: machineAPIVersion,
+						Kind:       "Machine",
+						Name:       machineName,
+						UID:        "1234",
+					}},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), snr)).To(Succeed())
+
+			// wait until reconciled
+			Eventually(func() bool {
+				return pprr.WasLastSeenSnrMachine()
+			}, 5*time.Second, 250*time.Millisecond).Should(BeTrue(), "SNR not reconciled")
+		})
+
+		AfterEach(func() {
+			deleteSnr(machineName)
+			setNodeAnnotation(machineAnnotation, "")
+		})
+
+		Context("of the OpenShift Machine API", func() {
+			BeforeEach(func() {
+				machineAPIVersion = "machine.openshift.io/v1beta1"
+				machineAnnotation = "machine.openshift.io/machine"
+				machineAnnotationValue = "default/" + machineName
+			})
+
+			It("should return unhealthy", func() {
+				verifyHealthResponse(phClient, api.Unhealthy)
+			})
+		})
+
+		Context("of the Cluster API", func() {
+			BeforeEach(func() {
+				machineAPIVersion = "cluster.x-k8s.io/v1beta1"
+				machineAnnotation = "cluster.x-k8s.io/machine"
+				machineAnnotationValue = machineName
+			})
+
+			It("should return unhealthy", func() {
+				verifyHealthResponse(phClient, api.Unhealthy)
+			})
+		})
+
+	})
+
+	Describe("checking node readiness", func() {
+
+		AfterEach(func() {
+			setNodeReady(v1.ConditionUnknown)
+		})
+
+		It("should return unhealthy for a node which isn't ready", func() {
+			setNodeReady(v1.ConditionUnknown)
+			verifyNodeReadyResponse(phClient, api.Unhealthy)
+		})
+
+		It("should return healthy for a ready node", func() {
+			setNodeReady(v1.ConditionTrue)
+			verifyNodeReadyResponse(phClient, api.Healthy)
+		})
+
+	})
+
+	Describe("getting the boot info", func() {
+
+		It("should return the boot info of this node", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			resp, err := phClient.GetBootInfo(ctx, &HealthRequest{
+				NodeName: nodeName,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.BootID).ToNot(BeEmpty())
+			Expect(resp.UptimeSeconds).To(BeNumerically(">", 0))
+		})
+
+		It("should fail for another node", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := phClient.GetBootInfo(ctx, &HealthRequest{
+				NodeName: "other-node",
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
 })
+
+func verifyHealthResponse(phClient *Client, expected api.HealthCheckResponseCode) {
+	By("calling isHealthy")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer (cancel)()
+	resp, err := phClient.IsHealthy(ctx, &HealthRequest{
+		NodeName: nodeName,
+	})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, api.HealthCheckResponseCode(resp.Status)).To(Equal(expected))
+}
+
+func verifyNodeReadyResponse(phClient *Client, expected api.HealthCheckResponseCode) {
+	By("calling isNodeReady")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer (cancel)()
+	resp, err := phClient.IsNodeReady(ctx, &HealthRequest{
+		NodeName: nodeName,
+	})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, api.HealthCheckResponseCode(resp.Status)).To(Equal(expected))
+}
+
+func deleteSnr(name string) {
+	snr := &v1alpha1.SelfNodeRemediation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+	ExpectWithOffset(1, k8sClient.Delete(context.Background(), snr)).To(Succeed())
+}
+
+// setNodeAnnotation sets the given annotation on the test node, an empty value removes it
+func setNodeAnnotation(key, value string) {
+	node := &v1.Node{}
+	ExpectWithOffset(1, k8sClient.Get(context.Background(), client.ObjectKey{Name: nodeName}, node)).To(Succeed())
+	patch := client.MergeFrom(node.DeepCopy())
+	if value == "" {
+		delete(node.Annotations, key)
+	} else {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[key] = value
+	}
+	ExpectWithOffset(1, k8sClient.Patch(context.Background(), node, patch)).To(Succeed())
+}
+
+// setNodeReady sets the status of the Ready condition of the test node
+func setNodeReady(status v1.ConditionStatus) {
+	node := &v1.Node{}
+	ExpectWithOffset(1, k8sClient.Get(context.Background(), client.ObjectKey{Name: nodeName}, node)).To(Succeed())
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+	ExpectWithOffset(1, k8sClient.Status().Update(context.Background(), node)).To(Succeed())
+}
@@ -114,6 +114,61 @@ func (x *HealthResponse) GetStatus() int32 {
 	return 0
 }
 
+type BootInfoResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BootID        string `protobuf:"bytes,1,opt,name=bootID,proto3" json:"bootID,omitempty"`
+	UptimeSeconds int64  `protobuf:"varint,2,opt,name=uptimeSeconds,proto3" json:"uptimeSeconds,omitempty"`
+}
+
+func (x *BootInfoResponse) Reset() {
+	*x = BootInfoResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_peerhealth_peerhealth_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BootInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BootInfoResponse) ProtoMessage() {}
+
+func (x *BootInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_peerhealth_peerhealth_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BootInfoResponse.ProtoReflect.Descriptor instead.
+func (*BootInfoResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_peerhealth_peerhealth_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BootInfoResponse) GetBootID() string {
+	if x != nil {
+		return x.BootID
+	}
+	return ""
+}
+
+func (x *BootInfoResponse) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
 var File_pkg_peerhealth_peerhealth_proto protoreflect.FileDescriptor
 
 var file_pkg_peerhealth_peerhealth_proto_rawDesc = []byte{
@@ -126,16 +181,41 @@ var file_pkg_peerhealth_peerhealth_proto_rawDesc = []byte{
 	0x52, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x28, 0x0a, 0x0e, 0x48, 0x65,
 	0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06,
 	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x32, 0x72, 0x0a, 0x0a, 0x50, 0x65, 0x65, 0x72, 0x48, 0x65, 0x61, 0x6c,
-	0x74, 0x68, 0x12, 0x64, 0x0a, 0x09, 0x49, 0x73, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x12,
-	0x29, 0x2e, 0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61,
-	0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a, 0x2e, 0x73, 0x65, 0x6c,
+	0x61, 0x74, 0x75, 0x73, 0x22, 0x50, 0x0a, 0x10, 0x42, 0x6f, 0x6f, 0x74, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6f, 0x6f, 0x74,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x6f, 0x6f, 0x74, 0x49, 0x44,
+	0x12, 0x24, 0x0a, 0x0d, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x32, 0xb0, 0x03, 0x0a, 0x0a, 0x50, 0x65, 0x65, 0x72, 0x48,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x64, 0x0a, 0x09, 0x49, 0x73, 0x48, 0x65, 0x61, 0x6c, 0x74,
+	0x68, 0x79, 0x12, 0x29, 0x2e, 0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d,
+	0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e,
+	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a, 0x2e,
+	0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74,
+	0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x66, 0x0a, 0x0b, 0x49,
+	0x73, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x29, 0x2e, 0x73, 0x65, 0x6c,
 	0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e,
 	0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x10, 0x5a, 0x0e, 0x70, 0x6b, 0x67, 0x2f,
-	0x70, 0x65, 0x65, 0x72, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a, 0x2e, 0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65,
+	0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x12, 0x68, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6f, 0x74, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x29, 0x2e, 0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d,
+	0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e,
+	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2c, 0x2e,
+	0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x42, 0x6f, 0x6f, 0x74, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x6a, 0x0a,
+	0x0f, 0x49, 0x73, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x61, 0x63, 0x68, 0x61, 0x62, 0x6c, 0x65,
+	0x12, 0x29, 0x2e, 0x73, 0x65, 0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64,
+	0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a, 0x2e, 0x73, 0x65,
+	0x6c, 0x66, 0x6e, 0x6f, 0x64, 0x65, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x10, 0x5a, 0x0e, 0x70, 0x6b, 0x67,
+	0x2f, 0x70, 0x65, 0x65, 0x72, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -150,16 +230,23 @@ func file_pkg_peerhealth_peerhealth_proto_rawDescGZIP() []byte {
 	return file_pkg_peerhealth_peerhealth_proto_rawDescData
 }
 
-var file_pkg_peerhealth_peerhealth_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pkg_peerhealth_peerhealth_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_pkg_peerhealth_peerhealth_proto_goTypes = []interface{}{
-	(*HealthRequest)(nil),  // 0: selfnoderemediation.health.HealthRequest
-	(*HealthResponse)(nil), // 1: selfnoderemediation.health.HealthResponse
+	(*HealthRequest)(nil),    // 0: selfnoderemediation.health.HealthRequest
+	(*HealthResponse)(nil),   // 1: selfnoderemediation.health.HealthResponse
+	(*BootInfoResponse)(nil), // 2: selfnoderemediation.health.BootInfoResponse
 }
 var file_pkg_peerhealth_peerhealth_proto_depIdxs = []int32{
 	0, // 0: selfnoderemediation.health.PeerHealth.IsHealthy:input_type -> selfnoderemediation.health.HealthRequest
-	1, // 1: selfnoderemediation.health.PeerHealth.IsHealthy:output_type -> selfnoderemediation.health.HealthResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	0, // 1: selfnoderemediation.health.PeerHealth.IsNodeReady:input_type -> selfnoderemediation.health.HealthRequest
+	0, // 2: selfnoderemediation.health.PeerHealth.GetBootInfo:input_type -> selfnoderemediation.health.HealthRequest
+	0, // 3: selfnoderemediation.health.PeerHealth.IsNodeReachable:input_type -> selfnoderemediation.health.HealthRequest
+	1, // 4: selfnoderemediation.health.PeerHealth.IsHealthy:output_type -> selfnoderemediation.health.HealthResponse
+	1, // 5: selfnoderemediation.health.PeerHealth.IsNodeReady:output_type -> selfnoderemediation.health.HealthResponse
+	2, // 6: selfnoderemediation.health.PeerHealth.GetBootInfo:output_type -> selfnoderemediation.health.BootInfoResponse
+	1, // 7: selfnoderemediation.health.PeerHealth.IsNodeReachable:output_type -> selfnoderemediation.health.HealthResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -195,6 +282,18 @@ func file_pkg_peerhealth_peerhealth_proto_init() {
 				return nil
 			}
 		}
+		file_pkg_peerhealth_peerhealth_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BootInfoResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -202,7 +301,7 @@ func file_pkg_peerhealth_peerhealth_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_peerhealth_peerhealth_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
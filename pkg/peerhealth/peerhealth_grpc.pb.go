@@ -19,6 +19,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PeerHealthClient interface {
 	IsHealthy(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	IsNodeReady(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	GetBootInfo(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*BootInfoResponse, error)
+	IsNodeReachable(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
 }
 
 type peerHealthClient struct {
@@ -38,11 +41,41 @@ func (c *peerHealthClient) IsHealthy(ctx context.Context, in *HealthRequest, opt
 	return out, nil
 }
 
+func (c *peerHealthClient) IsNodeReady(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/selfnoderemediation.health.PeerHealth/IsNodeReady", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerHealthClient) GetBootInfo(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*BootInfoResponse, error) {
+	out := new(BootInfoResponse)
+	err := c.cc.Invoke(ctx, "/selfnoderemediation.health.PeerHealth/GetBootInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerHealthClient) IsNodeReachable(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/selfnoderemediation.health.PeerHealth/IsNodeReachable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PeerHealthServer is the server API for PeerHealth service.
 // All implementations must embed UnimplementedPeerHealthServer
 // for forward compatibility
 type PeerHealthServer interface {
 	IsHealthy(context.Context, *HealthRequest) (*HealthResponse, error)
+	IsNodeReady(context.Context, *HealthRequest) (*HealthResponse, error)
+	GetBootInfo(context.Context, *HealthRequest) (*BootInfoResponse, error)
+	IsNodeReachable(context.Context, *HealthRequest) (*HealthResponse, error)
 	mustEmbedUnimplementedPeerHealthServer()
 }
 
@@ -53,6 +86,15 @@ type UnimplementedPeerHealthServer struct {
 func (UnimplementedPeerHealthServer) IsHealthy(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsHealthy not implemented")
 }
+func (UnimplementedPeerHealthServer) IsNodeReady(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsNodeReady not implemented")
+}
+func (UnimplementedPeerHealthServer) GetBootInfo(context.Context, *HealthRequest) (*BootInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBootInfo not implemented")
+}
+func (UnimplementedPeerHealthServer) IsNodeReachable(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsNodeReachable not implemented")
+}
 func (UnimplementedPeerHealthServer) mustEmbedUnimplementedPeerHealthServer() {}
 
 // UnsafePeerHealthServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +126,60 @@ func _PeerHealth_IsHealthy_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PeerHealth_IsNodeReady_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerHealthServer).IsNodeReady(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/selfnoderemediation.health.PeerHealth/IsNodeReady",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerHealthServer).IsNodeReady(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerHealth_GetBootInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerHealthServer).GetBootInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/selfnoderemediation.health.PeerHealth/GetBootInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerHealthServer).GetBootInfo(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerHealth_IsNodeReachable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerHealthServer).IsNodeReachable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/selfnoderemediation.health.PeerHealth/IsNodeReachable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerHealthServer).IsNodeReachable(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PeerHealth_ServiceDesc is the grpc.ServiceDesc for PeerHealth service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +191,18 @@ var PeerHealth_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IsHealthy",
 			Handler:    _PeerHealth_IsHealthy_Handler,
 		},
+		{
+			MethodName: "IsNodeReady",
+			Handler:    _PeerHealth_IsNodeReady_Handler,
+		},
+		{
+			MethodName: "GetBootInfo",
+			Handler:    _PeerHealth_GetBootInfo_Handler,
+		},
+		{
+			MethodName: "IsNodeReachable",
+			Handler:    _PeerHealth_IsNodeReachable_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/peerhealth/peerhealth.proto",
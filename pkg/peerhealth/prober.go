@@ -0,0 +1,169 @@
+package peerhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/credentials"
+	corev1 "k8s.io/api/core/v1"
+
+	selfNodeRemediationApis "github.com/medik8s/self-node-remediation/api"
+	"github.com/medik8s/self-node-remediation/controllers"
+	"github.com/medik8s/self-node-remediation/pkg/certificates"
+	"github.com/medik8s/self-node-remediation/pkg/endpoint"
+	"github.com/medik8s/self-node-remediation/pkg/peers"
+)
+
+// maxPingingPeers is the number of peers which are asked to ping the unhealthy node
+const maxPingingPeers = 3
+
+var _ controllers.NodeProber = &Prober{}
+
+// Prober probes unhealthy nodes by their peer health port, and optionally by pings of this agent and a few peers
+type Prober struct {
+	peers          *peers.Peers
+	certReader     certificates.CertStorageReader
+	port           int
+	dialTimeout    time.Duration
+	requestTimeout time.Duration
+	pingEnabled    bool
+	log            logr.Logger
+	clientCreds    credentials.TransportCredentials
+	mutex          sync.Mutex
+}
+
+// NewProber returns a new Prober. Nodes are pinged only if pingEnabled is true
+func NewProber(peers *peers.Peers, certReader certificates.CertStorageReader, port int, dialTimeout, requestTimeout time.Duration,
+	pingEnabled bool, log logr.Logger) *Prober {
+	return &Prober{
+		peers:          peers,
+		certReader:     certReader,
+		port:           port,
+		dialTimeout:    dialTimeout,
+		requestTimeout: requestTimeout,
+		pingEnabled:    pingEnabled,
+		log:            log,
+	}
+}
+
+// GetBootInfo asks the agent of the given node for its boot ID and uptime
+func (p *Prober) GetBootInfo(node *corev1.Node) (string, time.Duration, error) {
+	address := getNodeAddress(node)
+	if address == "" {
+		return "", 0, errors.New("node doesn't have an address")
+	}
+
+	phClient, err := p.newClient(address)
+	if err != nil {
+		return "", 0, err
+	}
+	defer phClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.requestTimeout)
+	defer cancel()
+
+	resp, err := phClient.GetBootInfo(ctx, &HealthRequest{NodeName: node.Name})
+	if err != nil {
+		return "", 0, err
+	}
+	return resp.BootID, time.Duration(resp.UptimeSeconds) * time.Second, nil
+}
+
+// IsReachable returns true if the given node replies to the pings of this agent or of any of the asked peers. It
+// returns false if pings are disabled
+func (p *Prober) IsReachable(node *corev1.Node) bool {
+	if !p.pingEnabled {
+		return false
+	}
+
+	address := getNodeAddress(node)
+	if address == "" {
+		return false
+	}
+	if err := endpoint.PingAddress(address); err == nil {
+		p.log.Info("node replies to pings", "node name", node.Name, "address", address)
+		return true
+	}
+
+	peersAddresses := p.getPingingPeersAddresses(address)
+	results := make(chan bool, len(peersAddresses))
+	for _, peerAddress := range peersAddresses {
+		go func(peerAddress string) {
+			results <- p.isReachableFromPeer(node.Name, peerAddress)
+		}(peerAddress)
+	}
+
+	isReachable := false
+	for range peersAddresses {
+		if <-results {
+			isReachable = true
+		}
+	}
+	return isReachable
+}
+
+// getPingingPeersAddresses returns the addresses of a few peers, excluding the given address of the probed node
+func (p *Prober) getPingingPeersAddresses(nodeAddress string) []string {
+	var addresses []string
+	for _, nodeAddresses := range append(p.peers.GetPeersAddresses(peers.Worker), p.peers.GetPeersAddresses(peers.ControlPlane)...) {
+		if len(addresses) == maxPingingPeers {
+			break
+		}
+		if len(nodeAddresses) == 0 || nodeAddresses[0].Address == "" || nodeAddresses[0].Address == nodeAddress {
+			continue
+		}
+		addresses = append(addresses, nodeAddresses[0].Address)
+	}
+	return addresses
+}
+
+// isReachableFromPeer asks the peer with the given address to ping the given node
+func (p *Prober) isReachableFromPeer(nodeName string, peerAddress string) bool {
+	logger := p.log.WithValues("IP", peerAddress)
+
+	phClient, err := p.newClient(peerAddress)
+	if err != nil {
+		logger.Error(err, "failed to init grpc client")
+		return false
+	}
+	defer phClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.requestTimeout)
+	defer cancel()
+
+	resp, err := phClient.IsNodeReachable(ctx, &HealthRequest{NodeName: nodeName})
+	if err != nil {
+		logger.Error(err, "failed to read reachability response from peer")
+		return false
+	}
+
+	status := selfNodeRemediationApis.HealthCheckResponseCode(resp.Status)
+	logger.Info("got reachability response from peer", "node name", nodeName, "status", status)
+	return status == selfNodeRemediationApis.Healthy
+}
+
+func (p *Prober) newClient(address string) (*Client, error) {
+	clientCreds, err := p.getClientCreds()
+	if err != nil {
+		return nil, err
+	}
+	// TODO does this work with IPv6?
+	return NewClient(fmt.Sprintf("%v:%v", address, p.port), p.dialTimeout, p.log.WithName("peerhealth client"), clientCreds)
+}
+
+func (p *Prober) getClientCreds() (credentials.TransportCredentials, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.clientCreds == nil {
+		clientCreds, err := certificates.GetClientCredentialsFromCerts(p.certReader)
+		if err != nil {
+			return nil, err
+		}
+		p.clientCreds = clientCreds
+	}
+	return p.clientCreds, nil
+}
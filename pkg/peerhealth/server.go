@@ -12,6 +12,7 @@ import (
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
@@ -21,11 +22,15 @@ import (
 	"github.com/medik8s/self-node-remediation/api/v1alpha1"
 	"github.com/medik8s/self-node-remediation/controllers"
 	"github.com/medik8s/self-node-remediation/pkg/certificates"
+	"github.com/medik8s/self-node-remediation/pkg/endpoint"
+	"github.com/medik8s/self-node-remediation/pkg/utils"
 )
 
 const (
 	connectionTimeout = 5 * time.Second
-	machineAnnotation = "machine.openshift.io/machine" //todo this is openshift specific
+	machineAnnotation = "machine.openshift.io/machine"
+	// Cluster API sets the machine name only, without the namespace
+	capiMachineAnnotation = "cluster.x-k8s.io/machine"
 	//IMPORTANT! this MUST be less than PeerRequestTimeout in apicheck
 	//The difference between them should allow some time for sending the request over the network
 	//todo enforce this
@@ -145,6 +150,101 @@ func (s Server) IsHealthy(ctx context.Context, request *HealthRequest) (*HealthR
 	}
 }
 
+// IsNodeReady checks if the given node is ready, in order to confirm a self-initiated remediation of a node which
+// considers itself broken. Nodes which aren't ready are reported as unhealthy
+func (s Server) IsNodeReady(ctx context.Context, request *HealthRequest) (*HealthResponse, error) {
+
+	nodeName := request.GetNodeName()
+	if nodeName == "" {
+		return nil, fmt.Errorf("empty node name in HealthRequest")
+	}
+
+	s.log.Info("checking readiness for", "node", nodeName)
+
+	unstructuredNode, err := s.getNode(ctx, nodeName)
+	if err != nil {
+		return toResponse(selfNodeRemediationApis.ApiError)
+	}
+
+	node := &corev1.Node{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredNode.UnstructuredContent(), node); err != nil {
+		s.log.Error(err, "failed to convert node")
+		return toResponse(selfNodeRemediationApis.ApiError)
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			s.log.Info("node is ready")
+			return toResponse(selfNodeRemediationApis.Healthy)
+		}
+	}
+	s.log.Info("node isn't ready")
+	return toResponse(selfNodeRemediationApis.Unhealthy)
+}
+
+// GetBootInfo returns the boot ID and the uptime of the node of this agent, so that the peers which are about to
+// fence it can detect a reboot which didn't happen
+func (s Server) GetBootInfo(ctx context.Context, request *HealthRequest) (*BootInfoResponse, error) {
+
+	// the address of the node might have been reused by another node
+	nodeName := request.GetNodeName()
+	if nodeName != s.snr.MyNodeName {
+		return nil, fmt.Errorf("boot info of node %s was requested from node %s", nodeName, s.snr.MyNodeName)
+	}
+
+	bootID, err := utils.GetLinuxBootID()
+	if err != nil {
+		s.log.Error(err, "failed to get boot ID")
+		return nil, err
+	}
+	uptime, err := utils.GetLinuxUptime()
+	if err != nil {
+		s.log.Error(err, "failed to get uptime")
+		return nil, err
+	}
+
+	s.log.Info("returning boot info", "boot ID", bootID, "uptime", uptime)
+	return &BootInfoResponse{
+		BootID:        bootID,
+		UptimeSeconds: int64(uptime.Seconds()),
+	}, nil
+}
+
+// IsNodeReachable pings the given node, in order to confirm that a node which is about to be fenced is unreachable.
+// Nodes which reply are reported as healthy
+func (s Server) IsNodeReachable(ctx context.Context, request *HealthRequest) (*HealthResponse, error) {
+
+	nodeName := request.GetNodeName()
+	if nodeName == "" {
+		return nil, fmt.Errorf("empty node name in HealthRequest")
+	}
+
+	s.log.Info("checking reachability of", "node", nodeName)
+
+	unstructuredNode, err := s.getNode(ctx, nodeName)
+	if err != nil {
+		return toResponse(selfNodeRemediationApis.ApiError)
+	}
+
+	node := &corev1.Node{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredNode.UnstructuredContent(), node); err != nil {
+		s.log.Error(err, "failed to convert node")
+		return toResponse(selfNodeRemediationApis.ApiError)
+	}
+
+	address := getNodeAddress(node)
+	if address == "" {
+		s.log.Info("node doesn't have an address")
+		return toResponse(selfNodeRemediationApis.Unhealthy)
+	}
+	if err := endpoint.PingAddress(address); err != nil {
+		s.log.Info("node isn't reachable", "address", address, "error", err.Error())
+		return toResponse(selfNodeRemediationApis.Unhealthy)
+	}
+	s.log.Info("node is reachable", "address", address)
+	return toResponse(selfNodeRemediationApis.Healthy)
+}
+
 func (s Server) isHealthyNode(ctx context.Context, nodeName string, namespace string) selfNodeRemediationApis.HealthCheckResponseCode {
 	return s.isHealthyBySnr(ctx, nodeName, namespace)
 }
@@ -157,6 +257,9 @@ func (s Server) isHealthyMachine(ctx context.Context, nodeName string, namespace
 
 	ann := node.GetAnnotations()
 	namespacedMachine, exists := ann[machineAnnotation]
+	if !exists {
+		namespacedMachine, exists = ann[capiMachineAnnotation]
+	}
 
 	if !exists {
 		s.log.Info("node doesn't have machine annotation")
@@ -176,7 +279,7 @@ func (s Server) isHealthyBySnr(ctx context.Context, snrName string, snrNamespace
 	apiCtx, cancelFunc := context.WithTimeout(ctx, apiServerTimeout)
 	defer cancelFunc()
 
-	_, err := s.client.Resource(snrRes).Namespace(snrNamespace).Get(apiCtx, snrName, metav1.GetOptions{})
+	unstructuredSnr, err := s.client.Resource(snrRes).Namespace(snrNamespace).Get(apiCtx, snrName, metav1.GetOptions{})
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
 			s.log.Info("node is healthy")
@@ -186,6 +289,17 @@ func (s Server) isHealthyBySnr(ctx context.Context, snrName string, snrNamespace
 		return selfNodeRemediationApis.ApiError
 	}
 
+	snr := &v1alpha1.SelfNodeRemediation{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredSnr.UnstructuredContent(), snr); err != nil {
+		s.log.Error(err, "failed to convert SNR")
+		return selfNodeRemediationApis.ApiError
+	}
+	if controllers.IsRemediationOnHold(snr) {
+		// the node must not reboot itself, e.g. because the remediation waits for an approval
+		s.log.Info("node is unhealthy, but its remediation is on hold")
+		return selfNodeRemediationApis.Healthy
+	}
+
 	s.log.Info("node is unhealthy")
 	return selfNodeRemediationApis.Unhealthy
 }
@@ -202,6 +316,19 @@ func (s Server) getNode(ctx context.Context, nodeName string) (*unstructured.Uns
 	return node, nil
 }
 
+// getNodeAddress returns the internal IP of the given node, or its first address if it doesn't have one
+func getNodeAddress(node *corev1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address
+		}
+	}
+	if len(node.Status.Addresses) > 0 {
+		return node.Status.Addresses[0].Address
+	}
+	return ""
+}
+
 func toResponse(status selfNodeRemediationApis.HealthCheckResponseCode) (*HealthResponse, error) {
 	return &HealthResponse{
 		Status: int32(status),
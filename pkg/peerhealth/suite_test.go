@@ -30,7 +30,10 @@ func TestPeerHealth(t *testing.T) {
 		[]Reporter{printer.NewlineReporter{}})
 }
 
-const nodeName = "somenode"
+const (
+	nodeName    = "somenode"
+	machineName = "somemachine"
+)
 
 var cfg *rest.Config
 var k8sClient client.Client
@@ -0,0 +1,290 @@
+package postmortem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	selfNodeRemediation "github.com/medik8s/self-node-remediation/api"
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+)
+
+const (
+	// hostRoot is the root filesystem of the host, which is accessible since the agent runs with hostPID
+	hostRoot = "/proc/1/root"
+	// bundleName is the name of the bundle file in the bundle directory
+	bundleName = "self-node-remediation-bundle"
+	// keptBundleTimeFormat is the format of the timestamp suffix of bundles which were already reported
+	keptBundleTimeFormat = "20060102T150405Z"
+	// maxDecisions and maxPeerResponses are the number of the latest decisions and peer responses which are kept
+	maxDecisions     = 20
+	maxPeerResponses = 20
+	// reportInterval is the interval for retrying to report the previous bundle, e.g. while the api server isn't
+	// reachable after the reboot
+	reportInterval = 10 * time.Second
+
+	eventReasonDiagnosticBundle = "DiagnosticBundle"
+)
+
+// Bundle holds the diagnostics which the agent collected before it rebooted its node
+type Bundle struct {
+	NodeName string    `json:"nodeName"`
+	Time     time.Time `json:"time"`
+	// RemediationNamespace and RemediationName identify the SelfNodeRemediation which rebooted the node, they're empty
+	// when the agent rebooted the node since it couldn't access the api server
+	RemediationNamespace string         `json:"remediationNamespace,omitempty"`
+	RemediationName      string         `json:"remediationName,omitempty"`
+	Decisions            []Decision     `json:"decisions,omitempty"`
+	PeerResponses        []PeerResponse `json:"peerResponses,omitempty"`
+}
+
+// Decision is a health decision of the api connectivity check
+type Decision struct {
+	Time      time.Time `json:"time"`
+	IsHealthy bool      `json:"isHealthy"`
+	Reason    string    `json:"reason"`
+}
+
+// PeerResponse is the response of a peer which was asked about the health of the node
+type PeerResponse struct {
+	Time    time.Time `json:"time"`
+	Address string    `json:"address"`
+	Status  string    `json:"status"`
+}
+
+// Collector collects the diagnostics of the node, writes them to a bundle on the host before the node is rebooted,
+// and reports the bundle after the reboot. All of its methods can be called on a nil Collector, which does nothing.
+type Collector struct {
+	// dir is the bundle directory, as seen by the agent
+	dir      string
+	nodeName string
+	client   client.Client
+	recorder record.EventRecorder
+	log      logr.Logger
+	mutex    sync.Mutex
+	bundle   Bundle
+}
+
+// NewCollector returns a collector which writes its bundles to the given host directory, or nil if it's empty
+func NewCollector(hostDir string, nodeName string, k8sClient client.Client, recorder record.EventRecorder, log logr.Logger) *Collector {
+	if hostDir == "" {
+		return nil
+	}
+	return newCollector(filepath.Join(hostRoot, hostDir), nodeName, k8sClient, recorder, log)
+}
+
+func newCollector(dir string, nodeName string, k8sClient client.Client, recorder record.EventRecorder, log logr.Logger) *Collector {
+	return &Collector{
+		dir:      dir,
+		nodeName: nodeName,
+		client:   k8sClient,
+		recorder: recorder,
+		log:      log,
+		bundle:   Bundle{NodeName: nodeName},
+	}
+}
+
+// RecordDecision records a health decision of the api connectivity check
+func (c *Collector) RecordDecision(isHealthy bool, reason string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bundle.Decisions = append(c.bundle.Decisions, Decision{Time: time.Now(), IsHealthy: isHealthy, Reason: reason})
+	if len(c.bundle.Decisions) > maxDecisions {
+		c.bundle.Decisions = c.bundle.Decisions[len(c.bundle.Decisions)-maxDecisions:]
+	}
+}
+
+// RecordPeerResponse records the response of the peer with the given address
+func (c *Collector) RecordPeerResponse(address string, status selfNodeRemediation.HealthCheckResponseCode) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bundle.PeerResponses = append(c.bundle.PeerResponses, PeerResponse{Time: time.Now(), Address: address, Status: status.String()})
+	if len(c.bundle.PeerResponses) > maxPeerResponses {
+		c.bundle.PeerResponses = c.bundle.PeerResponses[len(c.bundle.PeerResponses)-maxPeerResponses:]
+	}
+}
+
+// SetRemediation records the SelfNodeRemediation which is about to reboot the node
+func (c *Collector) SetRemediation(namespace, name string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bundle.RemediationNamespace = namespace
+	c.bundle.RemediationName = name
+}
+
+// WriteBundle writes the collected diagnostics to the bundle, and syncs it so that it survives the reboot
+func (c *Collector) WriteBundle() error {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	c.bundle.Time = time.Now()
+	data, err := json.MarshalIndent(c.bundle, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return writeFileSynced(c.dir, bundleName+".json", data)
+}
+
+// Start reports the bundle which was written before the previous reboot, if there is one
+func (c *Collector) Start(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	bundle, keptPath, err := c.takeBundle()
+	if err != nil {
+		c.log.Error(err, "failed to read the diagnostic bundle of the previous reboot")
+		return nil
+	}
+	if bundle == nil {
+		return nil
+	}
+	c.log.Info("found the diagnostic bundle of the previous reboot", "path", keptPath)
+
+	// the api server might not be reachable right after the reboot
+	go wait.PollImmediateUntil(reportInterval, func() (bool, error) {
+		if err := c.report(ctx, bundle, keptPath); err != nil {
+			c.log.Error(err, "failed to report the diagnostic bundle of the previous reboot, retrying")
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+	return nil
+}
+
+// takeBundle reads the bundle and renames it with a timestamp suffix, so that it's reported only once. It returns
+// nil if there is no bundle
+func (c *Collector) takeBundle() (*Bundle, string, error) {
+	data, err := os.ReadFile(c.bundlePath())
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	keptPath := filepath.Join(c.dir, fmt.Sprintf("%s-%s.json", bundleName, time.Now().UTC().Format(keptBundleTimeFormat)))
+	if err := os.Rename(c.bundlePath(), keptPath); err != nil {
+		return nil, "", err
+	}
+
+	bundle := &Bundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, "", err
+	}
+	return bundle, keptPath, nil
+}
+
+// report emits the summary of the bundle as an event of the SelfNodeRemediation which rebooted the node, or of the
+// node if the SelfNodeRemediation doesn't exist anymore
+func (c *Collector) report(ctx context.Context, bundle *Bundle, keptPath string) error {
+	summary := getSummary(bundle, strings.TrimPrefix(keptPath, hostRoot))
+
+	snr, err := c.getRemediation(ctx, bundle)
+	if err != nil {
+		return err
+	}
+	if snr != nil {
+		c.recorder.Event(snr, v1.EventTypeWarning, eventReasonDiagnosticBundle, summary)
+		return nil
+	}
+
+	node := &v1.Node{}
+	if err := c.client.Get(ctx, client.ObjectKey{Name: c.nodeName}, node); err != nil {
+		return err
+	}
+	c.recorder.Event(node, v1.EventTypeWarning, eventReasonDiagnosticBundle, summary)
+	return nil
+}
+
+// getRemediation returns the SelfNodeRemediation of the bundle, or the one of the node when the bundle doesn't name
+// one, or nil if it doesn't exist
+func (c *Collector) getRemediation(ctx context.Context, bundle *Bundle) (*v1alpha1.SelfNodeRemediation, error) {
+	if bundle.RemediationName != "" {
+		snr := &v1alpha1.SelfNodeRemediation{}
+		key := client.ObjectKey{Namespace: bundle.RemediationNamespace, Name: bundle.RemediationName}
+		if err := c.client.Get(ctx, key, snr); err != nil {
+			if apiErrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return snr, nil
+	}
+
+	snrs := &v1alpha1.SelfNodeRemediationList{}
+	if err := c.client.List(ctx, snrs); err != nil {
+		return nil, err
+	}
+	for i := range snrs.Items {
+		if snrs.Items[i].Name == bundle.NodeName {
+			return &snrs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Collector) bundlePath() string {
+	return filepath.Join(c.dir, bundleName+".json")
+}
+
+// getSummary returns a human readable summary of the bundle
+func getSummary(bundle *Bundle, keptPath string) string {
+	summary := fmt.Sprintf("node %s was rebooted at %s", bundle.NodeName, bundle.Time.UTC().Format(time.RFC3339))
+	if bundle.RemediationName != "" {
+		summary += fmt.Sprintf(" by remediation %s/%s", bundle.RemediationNamespace, bundle.RemediationName)
+	} else {
+		summary += " by its agent"
+	}
+
+	if len(bundle.Decisions) > 0 {
+		last := bundle.Decisions[len(bundle.Decisions)-1]
+		health := "unhealthy"
+		if last.IsHealthy {
+			health = "healthy"
+		}
+		summary += fmt.Sprintf("; last health decision: %s (%s)", health, last.Reason)
+	}
+
+	if len(bundle.PeerResponses) > 0 {
+		// the statuses are listed in the order of their first appearance
+		var statuses []string
+		counts := map[string]int{}
+		for _, response := range bundle.PeerResponses {
+			if counts[response.Status] == 0 {
+				statuses = append(statuses, response.Status)
+			}
+			counts[response.Status]++
+		}
+		responses := make([]string, len(statuses))
+		for i, status := range statuses {
+			responses[i] = fmt.Sprintf("%d %s", counts[status], status)
+		}
+		summary += fmt.Sprintf("; last peer responses: %s", strings.Join(responses, ", "))
+	}
+
+	return summary + fmt.Sprintf("; bundle kept at %s", keptPath)
+}
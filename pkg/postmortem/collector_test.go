@@ -0,0 +1,95 @@
+package postmortem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	selfNodeRemediation "github.com/medik8s/self-node-remediation/api"
+)
+
+func TestWriteAndTakeBundle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir := filepath.Join(t.TempDir(), "bundles")
+	c := newCollector(dir, "node1", nil, nil, ctrl.Log.WithName("postmortem"))
+
+	// there's nothing to take before the bundle is written
+	bundle, _, err := c.takeBundle()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bundle).To(BeNil())
+
+	for i := 0; i < maxDecisions+5; i++ {
+		c.RecordDecision(true, "healthy")
+	}
+	c.RecordDecision(false, "isolated")
+	c.RecordPeerResponse("10.0.0.1", selfNodeRemediation.Unhealthy)
+	c.SetRemediation("ns", "node1")
+	g.Expect(c.WriteBundle()).To(Succeed())
+
+	bundle, keptPath, err := c.takeBundle()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bundle).ToNot(BeNil())
+	g.Expect(bundle.NodeName).To(Equal("node1"))
+	g.Expect(bundle.RemediationNamespace).To(Equal("ns"))
+	g.Expect(bundle.RemediationName).To(Equal("node1"))
+	g.Expect(bundle.Decisions).To(HaveLen(maxDecisions))
+	g.Expect(bundle.Decisions[maxDecisions-1].IsHealthy).To(BeFalse())
+	g.Expect(bundle.PeerResponses).To(HaveLen(1))
+	g.Expect(bundle.PeerResponses[0].Status).To(Equal("Unhealthy"))
+
+	// the bundle is kept, and it's taken only once
+	g.Expect(keptPath).To(BeAnExistingFile())
+	g.Expect(filepath.Join(dir, bundleName+".json")).ToNot(BeAnExistingFile())
+	bundle, _, err = c.takeBundle()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bundle).To(BeNil())
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+}
+
+func TestNilCollector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewCollector("", "node1", nil, nil, ctrl.Log.WithName("postmortem"))
+	g.Expect(c).To(BeNil())
+	c.RecordDecision(false, "isolated")
+	c.RecordPeerResponse("10.0.0.1", selfNodeRemediation.Unhealthy)
+	c.SetRemediation("ns", "node1")
+	g.Expect(c.WriteBundle()).To(Succeed())
+}
+
+func TestGetSummary(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bundle := &Bundle{
+		NodeName: "node1",
+		Time:     time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Decisions: []Decision{
+			{IsHealthy: true, Reason: "healthy"},
+			{IsHealthy: false, Reason: "isolated"},
+		},
+		PeerResponses: []PeerResponse{
+			{Address: "10.0.0.1", Status: "RequestFailed"},
+			{Address: "10.0.0.2", Status: "Unhealthy"},
+			{Address: "10.0.0.3", Status: "RequestFailed"},
+		},
+	}
+	g.Expect(getSummary(bundle, "/var/log/snr/bundle.json")).To(Equal("node node1 was rebooted at 2022-01-02T03:04:05Z by its agent; " +
+		"last health decision: unhealthy (isolated); last peer responses: 2 RequestFailed, 1 Unhealthy; bundle kept at /var/log/snr/bundle.json"))
+
+	bundle.RemediationNamespace = "ns"
+	bundle.RemediationName = "node1"
+	bundle.Decisions = nil
+	bundle.PeerResponses = nil
+	g.Expect(getSummary(bundle, "/var/log/snr/bundle.json")).To(Equal("node node1 was rebooted at 2022-01-02T03:04:05Z by remediation ns/node1; " +
+		"bundle kept at /var/log/snr/bundle.json"))
+}
@@ -0,0 +1,40 @@
+package postmortem
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileSynced replaces the given file in the given directory atomically, so that a reboot while writing it
+// doesn't corrupt the previous one, and syncs it so that it survives a reboot
+func writeFileSynced(dirPath, name string, data []byte) error {
+	if err := os.MkdirAll(dirPath, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dirPath, name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dirPath, name)); err != nil {
+		return err
+	}
+	// the rename needs to be synced as well, the node might be crashed without syncing its filesystems
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
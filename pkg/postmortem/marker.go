@@ -0,0 +1,238 @@
+package postmortem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+)
+
+const (
+	// rebootMarkerDir is the host directory of the reboot marker
+	rebootMarkerDir  = "/var/lib/self-node-remediation"
+	rebootMarkerName = "reboot-marker.json"
+	// rebootMarkerValidity is the time after the marker was written, within which the node must have been rebooted
+	// for attributing the reboot to the agent. It allows for the escalation of the reboot methods, a slow shutdown and
+	// a crash dump
+	rebootMarkerValidity = time.Hour
+
+	eventReasonRebootedByAgent = "RebootedBySelfNodeRemediation"
+)
+
+// RebootReason is the reason for which the agent took its node down
+type RebootReason struct {
+	// RemediationNamespace, RemediationName and RemediationUID identify the SelfNodeRemediation which rebooted the
+	// node, they're empty when the agent rebooted the node since it couldn't access the api server
+	RemediationNamespace string    `json:"remediationNamespace,omitempty"`
+	RemediationName      string    `json:"remediationName,omitempty"`
+	RemediationUID       types.UID `json:"remediationUID,omitempty"`
+	Reason               string    `json:"reason"`
+	Time                 time.Time `json:"time"`
+	// BootID is the boot ID of the host when the marker was written, the marker is reported only after it changed
+	BootID string `json:"bootID,omitempty"`
+}
+
+// RebootMarker is a marker on the host, which tells the agent after a reboot that it rebooted the node itself, and
+// why. All of its methods can be called on a nil RebootMarker, which does nothing.
+type RebootMarker struct {
+	// dir is the marker directory, as seen by the agent
+	dir string
+	// getBootID returns the current boot ID of the host
+	getBootID func() (string, error)
+	// getUptime returns the uptime of the host
+	getUptime func() (time.Duration, error)
+}
+
+// NewRebootMarker returns the reboot marker of the host
+func NewRebootMarker() *RebootMarker {
+	return &RebootMarker{dir: filepath.Join(hostRoot, rebootMarkerDir), getBootID: utils.GetLinuxBootID, getUptime: utils.GetLinuxUptime}
+}
+
+// Write writes the marker with the given reason, it must be called before the node is taken down
+func (m *RebootMarker) Write(reason RebootReason) error {
+	if m == nil {
+		return nil
+	}
+	reason.Time = time.Now()
+	// without the boot ID the marker is still reported after the next start of the agent, even if the node wasn't
+	// rebooted, which is better than not reporting a reboot at all
+	reason.BootID, _ = m.getBootID()
+	data, err := json.Marshal(reason)
+	if err != nil {
+		return err
+	}
+	return writeFileSynced(m.dir, rebootMarkerName, data)
+}
+
+// Read returns the reason of the previous reboot, or nil if the agent didn't reboot the node
+func (m *RebootMarker) Read() (*RebootReason, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(m.path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	reason := &RebootReason{}
+	if err := json.Unmarshal(data, reason); err != nil {
+		return nil, err
+	}
+	return reason, nil
+}
+
+// Clear removes the marker, so that a later reboot isn't attributed to the agent
+func (m *RebootMarker) Clear() error {
+	if m == nil {
+		return nil
+	}
+	if err := os.Remove(m.path()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReadPreviousReboot returns the reason of the previous reboot, or nil if the agent didn't reboot the node. A marker
+// which was written during the current boot is ignored, e.g. when the agent restarted since the reboot failed, and
+// it's kept until the node is rebooted. A stale marker, which was written more than rebootMarkerValidity before the
+// node was booted, is ignored and removed, e.g. when the remediation ended without a reboot and the node was rebooted
+// by someone else later.
+func (m *RebootMarker) ReadPreviousReboot() (*RebootReason, error) {
+	reason, err := m.Read()
+	if err != nil || reason == nil {
+		return nil, err
+	}
+	if reason.BootID != "" {
+		bootID, err := m.getBootID()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the boot ID")
+		}
+		if bootID == reason.BootID {
+			return nil, nil
+		}
+	}
+	uptime, err := m.getUptime()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the uptime")
+	}
+	if bootTime := time.Now().Add(-uptime); bootTime.After(reason.Time.Add(rebootMarkerValidity)) {
+		return nil, m.Clear()
+	}
+	return reason, nil
+}
+
+// clearReported removes the marker if it still holds the given reason, so that a marker which was written in the
+// meantime isn't lost
+func (m *RebootMarker) clearReported(reported *RebootReason) error {
+	reason, err := m.Read()
+	if err != nil || reason == nil {
+		return err
+	}
+	if !reason.Time.Equal(reported.Time) {
+		return nil
+	}
+	return m.Clear()
+}
+
+func (m *RebootMarker) path() string {
+	return filepath.Join(m.dir, rebootMarkerName)
+}
+
+// RebootReporter reports the reason of the previous reboot, if the agent rebooted the node, as an event and an
+// annotation of the node, and clears the marker. The marker is kept when the reason couldn't be reported, so that
+// it's reported when the agent starts again, and when the node wasn't rebooted since it was written.
+type RebootReporter struct {
+	marker   *RebootMarker
+	nodeName string
+	reader   client.Reader
+	client   client.Client
+	recorder record.EventRecorder
+	log      logr.Logger
+}
+
+// NewRebootReporter returns a reporter of the previous reboot, which reads the node with the given reader, since it
+// might be called before the cache is synced
+func NewRebootReporter(marker *RebootMarker, nodeName string, reader client.Reader, k8sClient client.Client, recorder record.EventRecorder, log logr.Logger) *RebootReporter {
+	return &RebootReporter{
+		marker:   marker,
+		nodeName: nodeName,
+		reader:   reader,
+		client:   k8sClient,
+		recorder: recorder,
+		log:      log,
+	}
+}
+
+// Start reports the reason of the previous reboot, if there is one
+func (r *RebootReporter) Start(ctx context.Context) error {
+	reason, err := r.marker.ReadPreviousReboot()
+	if err != nil {
+		r.log.Error(err, "failed to read the reboot marker")
+		return nil
+	}
+	if reason == nil {
+		return nil
+	}
+	r.log.Info("found the reason of the previous reboot", "reason", reason.Reason)
+
+	// the api server might not be reachable right after the reboot
+	go wait.PollImmediateUntil(reportInterval, func() (bool, error) {
+		if err := r.report(ctx, reason); err != nil {
+			r.log.Error(err, "failed to report the reason of the previous reboot, retrying")
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+	return nil
+}
+
+func (r *RebootReporter) report(ctx context.Context, reason *RebootReason) error {
+	node := &v1.Node{}
+	if err := r.reader.Get(ctx, client.ObjectKey{Name: r.nodeName}, node); err != nil {
+		return errors.Wrapf(err, "failed to retrieve my node: "+r.nodeName)
+	}
+
+	value, err := json.Marshal(reason)
+	if err != nil {
+		return err
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[utils.LastRebootReasonAnnotation] = string(value)
+	if err := r.client.Update(ctx, node); err != nil {
+		return errors.Wrapf(err, "failed to add node annotation to node: "+node.Name)
+	}
+
+	r.recorder.Event(node, v1.EventTypeNormal, eventReasonRebootedByAgent, getRebootMessage(reason))
+
+	if err := r.marker.clearReported(reason); err != nil {
+		// the reason was reported, so don't report it again
+		r.log.Error(err, "failed to clear the reboot marker")
+	}
+	return nil
+}
+
+// getRebootMessage returns a human readable message of the reboot reason
+func getRebootMessage(reason *RebootReason) string {
+	remediation := "without access to the api server"
+	if reason.RemediationName != "" {
+		remediation = fmt.Sprintf("for remediation %s/%s", reason.RemediationNamespace, reason.RemediationName)
+	}
+	return fmt.Sprintf("node was taken down by its self node remediation agent at %s %s: %s",
+		reason.Time.UTC().Format(time.RFC3339), remediation, reason.Reason)
+}
@@ -0,0 +1,180 @@
+package postmortem
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+)
+
+func TestRebootMarker(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	marker := &RebootMarker{dir: t.TempDir(), getBootID: func() (string, error) { return "boot-1", nil }}
+
+	// there's no reason before the marker is written
+	reason, err := marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+
+	g.Expect(marker.Write(RebootReason{RemediationNamespace: "ns", RemediationName: "node1", Reason: "isolated"})).To(Succeed())
+	reason, err = marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).ToNot(BeNil())
+	g.Expect(reason.RemediationNamespace).To(Equal("ns"))
+	g.Expect(reason.RemediationName).To(Equal("node1"))
+	g.Expect(reason.Reason).To(Equal("isolated"))
+	g.Expect(reason.Time).To(BeTemporally("~", time.Now(), time.Minute))
+	g.Expect(reason.BootID).To(Equal("boot-1"))
+
+	g.Expect(marker.Clear()).To(Succeed())
+	reason, err = marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+	// clearing a cleared marker is fine
+	g.Expect(marker.Clear()).To(Succeed())
+}
+
+func TestReadPreviousReboot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bootID := "boot-1"
+	uptime := time.Hour
+	marker := &RebootMarker{dir: t.TempDir(), getBootID: func() (string, error) { return bootID, nil },
+		getUptime: func() (time.Duration, error) { return uptime, nil }}
+
+	// there's no previous reboot before the marker is written
+	reason, err := marker.ReadPreviousReboot()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+
+	// the node wasn't rebooted yet, e.g. the agent restarted since the reboot failed, so the marker is kept
+	g.Expect(marker.Write(RebootReason{Reason: "isolated"})).To(Succeed())
+	reason, err = marker.ReadPreviousReboot()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+	reason, err = marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).ToNot(BeNil())
+
+	// the node was rebooted
+	bootID = "boot-2"
+	uptime = time.Minute
+	reason, err = marker.ReadPreviousReboot()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).ToNot(BeNil())
+	g.Expect(reason.Reason).To(Equal("isolated"))
+	g.Expect(reason.BootID).To(Equal("boot-1"))
+}
+
+func TestReadStaleReboot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bootID := "boot-1"
+	uptime := 3 * time.Hour
+	marker := &RebootMarker{dir: t.TempDir(), getBootID: func() (string, error) { return bootID, nil },
+		getUptime: func() (time.Duration, error) { return uptime, nil }}
+
+	// the remediation ended without a reboot
+	g.Expect(marker.Write(RebootReason{RemediationNamespace: "ns", RemediationName: "node1", RemediationUID: "1234",
+		Reason: "isolated"})).To(Succeed())
+	reason, err := marker.ReadPreviousReboot()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+
+	// the node was rebooted by someone else long after the marker was written
+	bootID = "boot-2"
+	reason, err = marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	reason.Time = time.Now().Add(-2 * rebootMarkerValidity)
+	data, err := json.Marshal(reason)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writeFileSynced(marker.dir, rebootMarkerName, data)).To(Succeed())
+	uptime = time.Minute
+
+	reason, err = marker.ReadPreviousReboot()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+	// the stale marker is removed
+	reason, err = marker.Read()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reason).To(BeNil())
+}
+
+// fakeNodeClient holds a single node, all other methods of the client panic
+type fakeNodeClient struct {
+	client.Client
+	mutex sync.Mutex
+	node  *v1.Node
+}
+
+func (c *fakeNodeClient) Get(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.node.DeepCopyInto(obj.(*v1.Node))
+	return nil
+}
+
+func (c *fakeNodeClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.node = obj.(*v1.Node).DeepCopy()
+	return nil
+}
+
+func (c *fakeNodeClient) getNode() *v1.Node {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.node.DeepCopy()
+}
+
+func TestRebootReporter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bootID := "boot-1"
+	marker := &RebootMarker{dir: t.TempDir(), getBootID: func() (string, error) { return bootID, nil },
+		getUptime: func() (time.Duration, error) { return time.Minute, nil }}
+	g.Expect(marker.Write(RebootReason{RemediationNamespace: "ns", RemediationName: "node1", RemediationUID: "1234",
+		Reason: "isolated"})).To(Succeed())
+	bootID = "boot-2"
+
+	k8sClient := &fakeNodeClient{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}}
+	recorder := record.NewFakeRecorder(10)
+	reporter := NewRebootReporter(marker, "node1", k8sClient, k8sClient, recorder, ctrl.Log.WithName("postmortem"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.Expect(reporter.Start(ctx)).To(Succeed())
+
+	g.Eventually(func() string {
+		return k8sClient.getNode().Annotations[utils.LastRebootReasonAnnotation]
+	}).Should(ContainSubstring(`"remediationUID":"1234"`))
+	g.Eventually(recorder.Events).Should(Receive(ContainSubstring(eventReasonRebootedByAgent)))
+	g.Eventually(func() (*RebootReason, error) {
+		return marker.Read()
+	}).Should(BeNil())
+}
+
+func TestGetRebootMessage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	reason := &RebootReason{Reason: "isolated", Time: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)}
+	g.Expect(getRebootMessage(reason)).To(Equal("node was taken down by its self node remediation agent at 2022-01-02T03:04:05Z " +
+		"without access to the api server: isolated"))
+
+	reason.RemediationNamespace = "ns"
+	reason.RemediationName = "node1"
+	g.Expect(getRebootMessage(reason)).To(Equal("node was taken down by its self node remediation agent at 2022-01-02T03:04:05Z " +
+		"for remediation ns/node1: isolated"))
+}
@@ -0,0 +1,41 @@
+package postmortem
+
+import (
+	"github.com/medik8s/self-node-remediation/pkg/reboot"
+)
+
+var _ reboot.Rebooter = &rebooter{}
+
+// rebooter writes the diagnostic bundle before the node is taken down
+type rebooter struct {
+	reboot.Rebooter
+	collector *Collector
+}
+
+// NewRebooter returns a rebooter which writes the bundle of the given collector before it takes the node down by the
+// given rebooter
+func NewRebooter(r reboot.Rebooter, c *Collector) reboot.Rebooter {
+	return &rebooter{Rebooter: r, collector: c}
+}
+
+func (r *rebooter) Reboot() error {
+	r.writeBundle()
+	return r.Rebooter.Reboot()
+}
+
+func (r *rebooter) PowerOff() error {
+	r.writeBundle()
+	return r.Rebooter.PowerOff()
+}
+
+func (r *rebooter) Halt() error {
+	r.writeBundle()
+	return r.Rebooter.Halt()
+}
+
+// writeBundle writes the bundle, a failure doesn't prevent taking the node down
+func (r *rebooter) writeBundle() {
+	if err := r.collector.WriteBundle(); err != nil {
+		r.collector.log.Error(err, "failed to write the diagnostic bundle")
+	}
+}
@@ -0,0 +1,176 @@
+package reboot
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/utils"
+	"github.com/medik8s/self-node-remediation/pkg/watchdog"
+)
+
+const (
+	// softwareRebootTimeout is the time after which a software reboot is assumed to have failed
+	softwareRebootTimeout = 10 * time.Second
+	// sysRqTrigger is system wide, so the one of the agent's container triggers the host
+	sysRqTrigger = "/proc/sysrq-trigger"
+	// sysRqSyncDelay gives the emergency sync and remount, which run asynchronously, time to complete
+	sysRqSyncDelay = 2 * time.Second
+)
+
+// DefaultMethods are the reboot methods which are used when none are configured
+var DefaultMethods = []v1alpha1.RebootMethod{
+	v1alpha1.WatchdogRebootMethod,
+	v1alpha1.SystemctlRebootMethod,
+	v1alpha1.SysRqRebootMethod,
+	v1alpha1.SyscallRebootMethod,
+}
+
+// Method is a way of rebooting the node
+type Method interface {
+	// Name returns the name of the method
+	Name() v1alpha1.RebootMethod
+	// Trigger triggers the reboot, and returns an error if it couldn't be triggered
+	Trigger() error
+	// Timeout returns the time after which the reboot is assumed to have failed if the node is still running
+	Timeout() time.Duration
+}
+
+// NewMethod returns the reboot method with the given name. The watchdog may be nil, in which case the "Watchdog"
+// method fails
+func NewMethod(name v1alpha1.RebootMethod, wd watchdog.Watchdog, sysRqSync bool) (Method, error) {
+	switch name {
+	case v1alpha1.WatchdogRebootMethod:
+		return &watchdogMethod{wd: wd}, nil
+	case v1alpha1.SystemctlRebootMethod:
+		return &systemctlMethod{}, nil
+	case v1alpha1.SysRqRebootMethod:
+		return &sysRqMethod{path: sysRqTrigger, sync: sysRqSync}, nil
+	case v1alpha1.SyscallRebootMethod:
+		return &syscallMethod{}, nil
+	case v1alpha1.CrashRebootMethod:
+		return &crashMethod{sysRq: sysRqMethod{path: sysRqTrigger, sync: sysRqSync}}, nil
+	default:
+		return nil, errors.New("unsupported reboot method " + string(name))
+	}
+}
+
+// watchdogMethod stops feeding the watchdog, so that it reboots the node
+type watchdogMethod struct {
+	wd watchdog.Watchdog
+}
+
+func (m *watchdogMethod) Name() v1alpha1.RebootMethod {
+	return v1alpha1.WatchdogRebootMethod
+}
+
+func (m *watchdogMethod) Trigger() error {
+	if m.wd == nil {
+		return errors.New("no watchdog is present on this host")
+	}
+	switch m.wd.Status() {
+	case watchdog.Armed:
+		m.wd.Stop()
+		return nil
+	case watchdog.Triggered:
+		return nil
+	default:
+		return errors.New("watchdog isn't armed")
+	}
+}
+
+func (m *watchdogMethod) Timeout() time.Duration {
+	return TimeToAssumeRebootHasStarted
+}
+
+// systemctlMethod reboots the node by systemd, without stopping its services
+type systemctlMethod struct{}
+
+func (m *systemctlMethod) Name() v1alpha1.RebootMethod {
+	return v1alpha1.SystemctlRebootMethod
+}
+
+func (m *systemctlMethod) Trigger() error {
+	// hostPID: true and privileged:true required to run this
+	_, err := utils.RunHostCommand("/bin/systemctl", "reboot", "--force", "--force")
+	return err
+}
+
+func (m *systemctlMethod) Timeout() time.Duration {
+	return softwareRebootTimeout
+}
+
+// sysRqMethod reboots the node by the kernel's magic SysRq key, which doesn't depend on the host's user space
+type sysRqMethod struct {
+	path string
+	sync bool
+}
+
+func (m *sysRqMethod) Name() v1alpha1.RebootMethod {
+	return v1alpha1.SysRqRebootMethod
+}
+
+func (m *sysRqMethod) Trigger() error {
+	// "b" reboots immediately
+	return m.trigger("b")
+}
+
+// trigger writes the given command key, after syncing the filesystems if needed
+func (m *sysRqMethod) trigger(key string) error {
+	if m.sync {
+		// "s" syncs the filesystems, and "u" remounts them read-only
+		for _, syncKey := range []string{"s", "u"} {
+			if err := m.write(syncKey); err != nil {
+				return err
+			}
+		}
+		time.Sleep(sysRqSyncDelay)
+	}
+	return m.write(key)
+}
+
+func (m *sysRqMethod) write(key string) error {
+	return os.WriteFile(m.path, []byte(key), 0200)
+}
+
+func (m *sysRqMethod) Timeout() time.Duration {
+	return softwareRebootTimeout
+}
+
+// syscallMethod reboots the node by the reboot syscall, which requires the host's PID namespace
+type syscallMethod struct{}
+
+func (m *syscallMethod) Name() v1alpha1.RebootMethod {
+	return v1alpha1.SyscallRebootMethod
+}
+
+func (m *syscallMethod) Trigger() error {
+	unix.Sync()
+	return unix.Reboot(unix.LINUX_REBOOT_CMD_RESTART)
+}
+
+func (m *syscallMethod) Timeout() time.Duration {
+	return softwareRebootTimeout
+}
+
+// crashMethod crashes the kernel by the magic SysRq key, so that kdump captures a vmcore before the node reboots.
+// It leaves the watchdog armed, so that the watchdog still reboots the node when the crash fails.
+type crashMethod struct {
+	sysRq sysRqMethod
+}
+
+func (m *crashMethod) Name() v1alpha1.RebootMethod {
+	return v1alpha1.CrashRebootMethod
+}
+
+func (m *crashMethod) Trigger() error {
+	// "c" crashes the kernel
+	return m.sysRq.trigger("c")
+}
+
+func (m *crashMethod) Timeout() time.Duration {
+	return softwareRebootTimeout
+}
@@ -2,10 +2,11 @@ package reboot
 
 import (
 	"errors"
-	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/medik8s/self-node-remediation/pkg/utils"
 	"github.com/medik8s/self-node-remediation/pkg/watchdog"
 )
 
@@ -14,73 +15,108 @@ const TimeToAssumeRebootHasStarted = time.Second * 30
 type Rebooter interface {
 	// Reboot triggers a node reboot
 	Reboot() error
+	// PowerOff powers the node off, so that it's kept down until it's powered on again
+	PowerOff() error
+	// Halt halts the node, so that it's kept down until it's reset
+	Halt() error
 }
 
-var _ Rebooter = &WatchdogRebooter{}
+var _ Rebooter = &MethodsRebooter{}
 
-// WatchdogRebooter uses a watchdog for triggering reboots
-type WatchdogRebooter struct {
-	wd  watchdog.Watchdog
-	log logr.Logger
+// MethodsRebooter reboots the node with the first of its methods, and escalates to the next one when a method fails
+// or doesn't reboot the node within its timeout
+type MethodsRebooter struct {
+	methods []Method
+	wd      watchdog.Watchdog
+	log     logr.Logger
+	mutex   sync.Mutex
+	// rebooting is true while the methods are tried
+	rebooting bool
+	// lastErr is set when all methods of the last reboot failed
+	lastErr error
 }
 
-func NewWatchdogRebooter(wd watchdog.Watchdog, log logr.Logger) Rebooter {
-	return &WatchdogRebooter{
-		wd:  wd,
-		log: log,
+// NewRebooter returns a rebooter which tries the given methods in their order. The watchdog is disarmed when the
+// node is halted, it may be nil
+func NewRebooter(wd watchdog.Watchdog, methods []Method, log logr.Logger) Rebooter {
+	return &MethodsRebooter{
+		methods: methods,
+		wd:      wd,
+		log:     log,
 	}
 }
 
-func (r *WatchdogRebooter) Reboot() error {
-	if r.wd == nil {
-		r.log.Info("no watchdog is present on this host, trying software reboot")
-		//we couldn't init a watchdog so far but requested to be rebooted. we issue a software reboot
-		return r.softwareReboot()
+// NewWatchdogRebooter returns a rebooter which uses the watchdog, with a fallback to a software reboot by systemctl
+func NewWatchdogRebooter(wd watchdog.Watchdog, log logr.Logger) Rebooter {
+	return NewRebooter(wd, []Method{&watchdogMethod{wd: wd}, &systemctlMethod{}}, log)
+}
+
+// Reboot starts rebooting the node in the background, unless it's being rebooted already.
+// It returns an error if the previous reboot failed with all methods, the reboot is retried nevertheless
+func (r *MethodsRebooter) Reboot() error {
+	if len(r.methods) == 0 {
+		return errors.New("no reboot method is available")
 	}
 
-	//Watch dog is rebooting, wait to make sure watchdog is rebooting properly otherwise intervene with software reboot
-	switch r.wd.Status() {
-	case watchdog.Triggered:
-		r.log.Info("watchdog is triggered, waiting for watchdog reboot to commence")
-		if r.isWatchdogRebootStuck() {
-			return r.softwareReboot()
-		}
-		return nil
-	case watchdog.Disarmed:
-		r.log.Info("watchdog failed to start, trying software reboot")
-		return r.softwareReboot()
-	case watchdog.Armed:
-		// we stop feeding the watchdog for a reboot
-		r.wd.Stop()
-		r.log.Info("watchdog feeding has stopped, waiting for reboot to commence")
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.rebooting {
+		r.log.Info("waiting for reboot to commence")
 		return nil
-	default:
-		err := errors.New("unexpected watchdog status")
-		r.log.Error(err, err.Error(), "watchDog status", r.wd.Status())
-		return err
 	}
+
+	err := r.lastErr
+	r.lastErr = nil
+	r.rebooting = true
+	go r.reboot()
+	return err
 }
 
-// softwareReboot performs software reboot by running systemctl reboot
-func (r *WatchdogRebooter) softwareReboot() error {
-	r.log.Info("about to try software reboot")
-	// hostPID: true and privileged:true required to run this
-	rebootCmd := exec.Command("/usr/bin/nsenter", "-m/proc/1/ns/mnt", "/bin/systemctl", "reboot", "--force", "--force")
+func (r *MethodsRebooter) reboot() {
+	for _, method := range r.methods {
+		r.log.Info("about to reboot", "method", method.Name())
+		if err := method.Trigger(); err != nil {
+			r.log.Error(err, "failed to trigger reboot, trying the next method", "method", method.Name())
+			continue
+		}
 
-	if err := rebootCmd.Run(); err != nil {
-		r.log.Error(err, "failed to run reboot command")
-		// TODO retry because of this?
+		// we are still running after the timeout only if the reboot didn't take effect
+		time.Sleep(method.Timeout())
+		r.log.Info("reboot is stuck, trying the next method", "method", method.Name(), "timeout", method.Timeout())
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rebooting = false
+	r.lastErr = errors.New("failed to reboot with all methods")
+	r.log.Error(r.lastErr, "reboot failed")
+}
+
+// PowerOff powers the node off, which the watchdog can't undo. The node is rebooted instead if powering it off fails
+func (r *MethodsRebooter) PowerOff() error {
+	r.log.Info("about to power off")
+	if _, err := utils.RunHostCommand("/bin/systemctl", "poweroff", "--force", "--force"); err != nil {
+		r.log.Error(err, "failed to run power off command, rebooting instead")
+		return r.Reboot()
 	}
 	return nil
 }
 
-func (r *WatchdogRebooter) isWatchdogRebootStuck() bool {
-	lastFoodTime := r.wd.LastFoodTime()
-	timeElapsedSinceLastFeed := time.Now().Sub(lastFoodTime)
-	var isStuck bool
-	if isStuck = timeElapsedSinceLastFeed > TimeToAssumeRebootHasStarted; isStuck {
-		r.log.Info("watchdog reboot is stuck, too long has passed since last feed time", "time passed since last feed", timeElapsedSinceLastFeed)
+// Halt disarms the watchdog, so that it doesn't reset the halted node, and halts the node.
+// The node is powered off instead if the watchdog can't be disarmed, e.g. when its driver doesn't allow it
+func (r *MethodsRebooter) Halt() error {
+	if r.wd != nil {
+		if err := r.wd.Disarm(); err != nil {
+			r.log.Error(err, "failed to disarm watchdog, powering off instead of halting")
+			return r.PowerOff()
+		}
 	}
 
-	return isStuck
+	r.log.Info("about to halt")
+	if _, err := utils.RunHostCommand("/bin/systemctl", "halt", "--force", "--force"); err != nil {
+		r.log.Error(err, "failed to run halt command, rebooting instead")
+		// the watchdog is disarmed already, so the software reboot methods are used
+		return r.Reboot()
+	}
+	return nil
 }
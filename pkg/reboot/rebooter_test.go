@@ -0,0 +1,127 @@
+package reboot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/medik8s/self-node-remediation/api/v1alpha1"
+	"github.com/medik8s/self-node-remediation/pkg/watchdog"
+)
+
+// fakeMethod records its triggers, it never reboots the node
+type fakeMethod struct {
+	name     v1alpha1.RebootMethod
+	err      error
+	mutex    *sync.Mutex
+	triggers *[]v1alpha1.RebootMethod
+}
+
+func (m *fakeMethod) Name() v1alpha1.RebootMethod {
+	return m.name
+}
+
+func (m *fakeMethod) Trigger() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	*m.triggers = append(*m.triggers, m.name)
+	return m.err
+}
+
+func (m *fakeMethod) Timeout() time.Duration {
+	return 100 * time.Millisecond
+}
+
+func TestReboot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mutex := &sync.Mutex{}
+	var triggers []v1alpha1.RebootMethod
+	getTriggers := func() []v1alpha1.RebootMethod {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]v1alpha1.RebootMethod{}, triggers...)
+	}
+	methods := []Method{
+		&fakeMethod{name: v1alpha1.WatchdogRebootMethod, err: errors.New("no watchdog"), mutex: mutex, triggers: &triggers},
+		&fakeMethod{name: v1alpha1.SysRqRebootMethod, mutex: mutex, triggers: &triggers},
+		&fakeMethod{name: v1alpha1.SyscallRebootMethod, mutex: mutex, triggers: &triggers},
+	}
+	rebooter := NewRebooter(nil, methods, ctrl.Log.WithName("rebooter"))
+
+	// a failed method and a method which doesn't take effect in time escalate to the next method
+	g.Expect(rebooter.Reboot()).To(Succeed())
+	g.Eventually(getTriggers, time.Second, 10*time.Millisecond).Should(Equal([]v1alpha1.RebootMethod{
+		v1alpha1.WatchdogRebootMethod, v1alpha1.SysRqRebootMethod, v1alpha1.SyscallRebootMethod,
+	}))
+
+	// methods aren't triggered again while the reboot is in progress
+	g.Expect(rebooter.Reboot()).To(Succeed())
+	g.Expect(getTriggers()).To(HaveLen(3))
+
+	// the failure of all methods is reported, and the reboot is retried
+	g.Eventually(rebooter.Reboot, time.Second, 10*time.Millisecond).Should(HaveOccurred())
+	g.Eventually(getTriggers, time.Second, 10*time.Millisecond).Should(HaveLen(6))
+
+	g.Expect(NewRebooter(nil, nil, ctrl.Log.WithName("rebooter")).Reboot()).ToNot(Succeed())
+}
+
+func TestWatchdogMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect((&watchdogMethod{}).Trigger()).ToNot(Succeed())
+
+	wd, err := watchdog.NewFake(ctrl.Log.WithName("watchdog"))
+	g.Expect(err).ToNot(HaveOccurred())
+	method := &watchdogMethod{wd: wd}
+	// the watchdog isn't started
+	g.Expect(method.Trigger()).ToNot(Succeed())
+}
+
+func TestSysRqMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	path := filepath.Join(t.TempDir(), "sysrq-trigger")
+	g.Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+
+	g.Expect((&sysRqMethod{path: path}).Trigger()).To(Succeed())
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("b"))
+
+	g.Expect((&sysRqMethod{path: filepath.Join(t.TempDir(), "missing", "sysrq-trigger")}).Trigger()).ToNot(Succeed())
+}
+
+func TestCrashMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	path := filepath.Join(t.TempDir(), "sysrq-trigger")
+	g.Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+
+	wd, err := watchdog.NewFake(ctrl.Log.WithName("watchdog"))
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = wd.Start(ctx)
+	}()
+	g.Eventually(wd.Status).Should(Equal(watchdog.Armed))
+
+	g.Expect((&crashMethod{sysRq: sysRqMethod{path: path}}).Trigger()).To(Succeed())
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("c"))
+	// the watchdog stays armed, so that it reboots the node if the crash fails
+	g.Expect(wd.Status()).To(Equal(watchdog.Armed))
+
+	g.Expect((&crashMethod{sysRq: sysRqMethod{path: filepath.Join(t.TempDir(), "missing", "sysrq-trigger")}}).Trigger()).ToNot(Succeed())
+	g.Expect(wd.Status()).To(Equal(watchdog.Armed))
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWindowDuration limits the duration of windows, since finding the start of a window is done minute by minute
+const maxWindowDuration = 7 * 24 * time.Hour
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	minuteField     = cronField{"minute", 0, 59}
+	hourField       = cronField{"hour", 0, 23}
+	dayOfMonthField = cronField{"day of month", 1, 31}
+	monthField      = cronField{"month", 1, 12}
+	// both 0 and 7 are Sunday
+	dayOfWeekField = cronField{"day of week", 0, 7}
+)
+
+// Cron is a parsed cron expression with the standard fields "minute hour day-of-month month day-of-week".
+// Each field supports "*", single values, ranges "a-b", steps "*/n" or "a-b/n", and comma separated lists of them.
+type Cron struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// like in cron, when both day fields are restricted, a time matches if either of them matches
+	isDayOfMonthRestricted bool
+	isDayOfWeekRestricted  bool
+}
+
+// ParseCron parses the given cron expression
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, found %d", expr, len(fields))
+	}
+
+	c := &Cron{
+		isDayOfMonthRestricted: fields[2] != "*",
+		isDayOfWeekRestricted:  fields[4] != "*",
+	}
+	var err error
+	if c.minutes, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if c.daysOfMonth, err = parseField(fields[2], dayOfMonthField); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if c.daysOfWeek, err = parseField(fields[4], dayOfWeekField); err != nil {
+		return nil, err
+	}
+	if c.daysOfWeek&(1<<7) != 0 {
+		c.daysOfWeek |= 1
+	}
+	return c, nil
+}
+
+// parseField returns a bitset of the values which match the given field
+func parseField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s field", part[i+1:], field.name)
+			}
+			rangePart = part[:i]
+		}
+
+		start, end := field.min, field.max
+		if rangePart != "*" {
+			var err error
+			bounds := strings.SplitN(rangePart, "-", 2)
+			if start, err = parseValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseValue(bounds[1], field); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// "a/n" means from a to the max value
+				end = field.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q of %s field", rangePart, field.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, field cronField) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("invalid value %q of %s field, it must be between %d and %d", value, field.name, field.min, field.max)
+	}
+	return v, nil
+}
+
+// Matches returns true if the minute of the given time matches the cron expression
+func (c *Cron) Matches(t time.Time) bool {
+	if c.minutes&(1<<uint(t.Minute())) == 0 || c.hours&(1<<uint(t.Hour())) == 0 || c.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dayOfMonthMatches := c.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeekMatches := c.daysOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.isDayOfMonthRestricted && c.isDayOfWeekRestricted {
+		return dayOfMonthMatches || dayOfWeekMatches
+	}
+	return dayOfMonthMatches && dayOfWeekMatches
+}
+
+// IsInWindow returns true if the given time is within a window which starts whenever the cron expression matches,
+// and lasts for the given duration
+func (c *Cron) IsInWindow(t time.Time, duration time.Duration) bool {
+	if duration > maxWindowDuration {
+		duration = maxWindowDuration
+	}
+	for start := t.Truncate(time.Minute); t.Sub(start) < duration; start = start.Add(-time.Minute) {
+		if c.Matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWindow validates the cron expression and the duration of a window
+func ValidateWindow(expr string, duration time.Duration) error {
+	if _, err := ParseCron(expr); err != nil {
+		return err
+	}
+	if duration <= 0 || duration > maxWindowDuration {
+		return fmt.Errorf("window duration must be greater than 0 and at most %s", maxWindowDuration)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseCron(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, expr := range []string{"* * * * *", "0 2 * * 6", "*/15 1-5 1,15 * 1-5", "30 22 * 1-3/2 0,7", "5/10 * * * *"} {
+		_, err := ParseCron(expr)
+		g.Expect(err).ToNot(HaveOccurred(), expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		g.Expect(err).To(HaveOccurred(), expr)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Saturday
+	saturday := time.Date(2022, time.October, 15, 2, 0, 0, 0, time.UTC)
+
+	c, err := ParseCron("0 2 * * 6")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Matches(saturday)).To(BeTrue())
+	g.Expect(c.Matches(saturday.Add(time.Minute))).To(BeFalse())
+	g.Expect(c.Matches(saturday.Add(24 * time.Hour))).To(BeFalse())
+
+	// Sunday as 7
+	c, err = ParseCron("0 2 * * 7")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Matches(saturday.Add(24 * time.Hour))).To(BeTrue())
+
+	// either day field matches when both are restricted
+	c, err = ParseCron("0 2 1 * 6")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Matches(saturday)).To(BeTrue())
+	g.Expect(c.Matches(time.Date(2022, time.November, 1, 2, 0, 0, 0, time.UTC))).To(BeTrue())
+	g.Expect(c.Matches(time.Date(2022, time.November, 2, 2, 0, 0, 0, time.UTC))).To(BeFalse())
+
+	// steps
+	c, err = ParseCron("*/20 * * * *")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Matches(saturday.Add(40 * time.Minute))).To(BeTrue())
+	g.Expect(c.Matches(saturday.Add(30 * time.Minute))).To(BeFalse())
+}
+
+func TestIsInWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	saturday := time.Date(2022, time.October, 15, 2, 0, 0, 0, time.UTC)
+	c, err := ParseCron("0 2 * * 6")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(c.IsInWindow(saturday, time.Hour)).To(BeTrue())
+	g.Expect(c.IsInWindow(saturday.Add(59*time.Minute), time.Hour)).To(BeTrue())
+	g.Expect(c.IsInWindow(saturday.Add(time.Hour), time.Hour)).To(BeFalse())
+	g.Expect(c.IsInWindow(saturday.Add(-time.Minute), time.Hour)).To(BeFalse())
+	g.Expect(c.IsInWindow(saturday.Add(30*time.Hour), 48*time.Hour)).To(BeTrue())
+}
+
+func TestValidateWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ValidateWindow("0 2 * * 6", time.Hour)).To(Succeed())
+	g.Expect(ValidateWindow("0 2 * * 6", 0)).ToNot(Succeed())
+	g.Expect(ValidateWindow("0 2 * * 6", 8*24*time.Hour)).ToNot(Succeed())
+	g.Expect(ValidateWindow("0 2 * *", time.Hour)).ToNot(Succeed())
+}
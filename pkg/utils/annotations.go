@@ -13,6 +13,32 @@ import (
 const (
 	// IsRebootCapableAnnotation value is the key name for the node's annotation that will determine if node is reboot capable
 	IsRebootCapableAnnotation = "is-reboot-capable.self-node-remediation.medik8s.io"
+	// RemediationHistoryAnnotation value is the key name for the node's annotation that holds the comma separated
+	// start times of the node's recent remediations
+	RemediationHistoryAnnotation = "remediation-history.self-node-remediation.medik8s.io"
+	// RemediationApprovalAnnotation value is the key name for the SelfNodeRemediation's annotation which approves or
+	// rejects a remediation that requires an approval
+	RemediationApprovalAnnotation = "remediation-approval.self-node-remediation.medik8s.io"
+	// RemediationApproved is the value of RemediationApprovalAnnotation which approves the remediation
+	RemediationApproved = "approved"
+	// RemediationRejected is the value of RemediationApprovalAnnotation which rejects the remediation
+	RemediationRejected = "rejected"
+	// SelfInitiatedRemediationAnnotation value is the key name for the SelfNodeRemediation's annotation which marks
+	// a remediation that the agent of the unhealthy node created itself, its value is the reason of the remediation
+	SelfInitiatedRemediationAnnotation = "self-initiated.self-node-remediation.medik8s.io"
+	// LastRebootReasonAnnotation value is the key name for the node's annotation that holds the reason for which the
+	// agent took the node down the last time, as reported by the agent after the node was up again
+	LastRebootReasonAnnotation = "last-reboot-reason.self-node-remediation.medik8s.io"
+
+	// MachineConfigStateAnnotation is the node annotation which the machine-config-operator daemon uses for the
+	// state of the node's update
+	MachineConfigStateAnnotation = "machineconfiguration.openshift.io/state"
+	// MachineConfigStateWorking is the value of MachineConfigStateAnnotation while the node is being updated
+	MachineConfigStateWorking = "Working"
+	// MachineConfigCurrentConfigAnnotation is the node annotation with the machine config which the node runs
+	MachineConfigCurrentConfigAnnotation = "machineconfiguration.openshift.io/currentConfig"
+	// MachineConfigDesiredConfigAnnotation is the node annotation with the machine config which the node is updated to
+	MachineConfigDesiredConfigAnnotation = "machineconfiguration.openshift.io/desiredConfig"
 )
 
 // UpdateNodeWithIsRebootCapableAnnotation updates the is-reboot-capable node annotation to be true if any kind
@@ -27,10 +53,9 @@ func UpdateNodeWithIsRebootCapableAnnotation(watchdogInitiated bool, nodeName st
 		return errors.Wrapf(err, "failed to retrieve my node: "+nodeName)
 	}
 
-	softwareRebootEnabledEnv := os.Getenv("IS_SOFTWARE_REBOOT_ENABLED")
-	softwareRebootEnabled, err := strconv.ParseBool(softwareRebootEnabledEnv)
+	softwareRebootEnabled, err := IsSoftwareRebootEnabled()
 	if err != nil {
-		return errors.Wrapf(err, "failed to convert IS_SOFTWARE_REBOOT_ENABLED env valueto boolean. value is: %s", softwareRebootEnabledEnv)
+		return err
 	}
 
 	if node.Annotations == nil {
@@ -49,3 +74,13 @@ func UpdateNodeWithIsRebootCapableAnnotation(watchdogInitiated bool, nodeName st
 
 	return nil
 }
+
+// IsSoftwareRebootEnabled returns true if the agent may fall back to a software reboot
+func IsSoftwareRebootEnabled() (bool, error) {
+	softwareRebootEnabledEnv := os.Getenv("IS_SOFTWARE_REBOOT_ENABLED")
+	softwareRebootEnabled, err := strconv.ParseBool(softwareRebootEnabledEnv)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to convert IS_SOFTWARE_REBOOT_ENABLED env valueto boolean. value is: %s", softwareRebootEnabledEnv)
+	}
+	return softwareRebootEnabled, nil
+}
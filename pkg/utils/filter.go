@@ -0,0 +1,25 @@
+package utils
+
+import "strings"
+
+// IsKeyAllowed returns true if the key doesn't match the denylist, and matches the allowlist or the allowlist is empty.
+// An entry which ends with "*" matches all keys with the same prefix
+func IsKeyAllowed(key string, allowlist []string, denylist []string) bool {
+	if matchesAny(key, denylist) {
+		return false
+	}
+	return len(allowlist) == 0 || matchesAny(key, allowlist)
+}
+
+func matchesAny(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
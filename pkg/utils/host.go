@@ -0,0 +1,9 @@
+package utils
+
+import "os/exec"
+
+// RunHostCommand runs a command in the mount namespace of the host, and returns its standard output
+func RunHostCommand(name string, args ...string) ([]byte, error) {
+	// hostPID: true and privileged:true required to run this
+	return exec.Command("/usr/bin/nsenter", append([]string{"-m/proc/1/ns/mnt", name}, args...)...).Output()
+}
@@ -2,9 +2,14 @@ package utils
 
 import (
 	"golang.org/x/sys/unix"
+	"os"
+	"strings"
 	"time"
 )
 
+// bootIDPath is the boot ID of the host, which the kubelet reports as the node's boot ID
+const bootIDPath = "/proc/sys/kernel/random/boot_id"
+
 // GetLinuxUptime returns the uptime of a linux host
 func GetLinuxUptime() (time.Duration, error) {
 	si := &unix.Sysinfo_t{}
@@ -17,3 +22,12 @@ func GetLinuxUptime() (time.Duration, error) {
 	uptime := time.Duration(si.Uptime) * time.Second
 	return uptime, nil
 }
+
+// GetLinuxBootID returns the boot ID of a linux host, which changes whenever the host is rebooted
+func GetLinuxBootID() (string, error) {
+	bootID, err := os.ReadFile(bootIDPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bootID)), nil
+}
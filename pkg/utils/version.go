@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// out-of-service taint (non-graceful node shutdown) is enabled by default since k8s 1.26
+	minK8sMajorVersionOutOfServiceTaint = 1
+	minK8sMinorVersionOutOfServiceTaint = 26
+)
+
+var (
+	// IsOutOfServiceTaintSupported will be set to true in case the out-of-service taint is supported by the cluster
+	IsOutOfServiceTaintSupported = false
+
+	leadingDigits = regexp.MustCompile(`^(\d+)`)
+)
+
+// InitOutOfServiceTaintSupportedFlag checks the server version and sets IsOutOfServiceTaintSupported accordingly
+func InitOutOfServiceTaintSupportedFlag(config *rest.Config) error {
+	cs, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to create discovery client")
+	}
+
+	version, err := cs.ServerVersion()
+	if err != nil {
+		return errors.Wrap(err, "failed to get server version")
+	}
+
+	major, err := parseVersionPart(version.Major)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse server major version: %s", version.Major)
+	}
+
+	// minor version might have a suffix, e.g. "26+" on some managed clusters
+	minor, err := parseVersionPart(version.Minor)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse server minor version: %s", version.Minor)
+	}
+
+	IsOutOfServiceTaintSupported = major > minK8sMajorVersionOutOfServiceTaint ||
+		(major == minK8sMajorVersionOutOfServiceTaint && minor >= minK8sMinorVersionOutOfServiceTaint)
+	return nil
+}
+
+func parseVersionPart(part string) (int, error) {
+	digits := leadingDigits.FindString(part)
+	if digits == "" {
+		return 0, errors.New("version doesn't start with a number")
+	}
+	return strconv.Atoi(digits)
+}
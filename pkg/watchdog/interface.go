@@ -13,6 +13,8 @@ type Watchdog interface {
 	Status() watchdogStatus
 	// Stop stops feeding the watchdog, which results in a reboot of the node
 	Stop()
+	// Disarm disarms the watchdog, so that it doesn't reboot the node although it isn't fed anymore
+	Disarm() error
 	// GetTimeout returns the watchdog timeout when it reboots the node without feeding
 	GetTimeout() time.Duration
 	// LastFoodTime return the last time the watchdog was fed
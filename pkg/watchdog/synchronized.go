@@ -100,6 +100,21 @@ func (swd *synchronizedWatchdog) Stop() {
 	}
 }
 
+func (swd *synchronizedWatchdog) Disarm() error {
+	swd.mutex.Lock()
+	defer swd.mutex.Unlock()
+	if swd.status == Disarmed {
+		return nil
+	}
+	if err := swd.impl.disarm(); err != nil {
+		return err
+	}
+	swd.stop()
+	swd.status = Disarmed
+	swd.log.Info("disarmed watchdog")
+	return nil
+}
+
 func (swd *synchronizedWatchdog) GetTimeout() time.Duration {
 	swd.mutex.Lock()
 	defer swd.mutex.Unlock()